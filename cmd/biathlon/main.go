@@ -1,45 +1,96 @@
 package main
 
 import (
+	"errors"
+	"flag"
 	"fmt"
 	"log"
 	"os"
+	"time"
 
 	"github.com/Chernovuk/biathlon-competetions/internal/biathlon"
 	"github.com/Chernovuk/biathlon-competetions/internal/statistics"
 )
 
 func main() {
-	if len(os.Args) != 3 {
-		fmt.Printf("Usage: %v EVENTS_FILEPATH CONFIG_FILEPATH\n", os.Args[0])
+	follow := flag.Bool("follow", false, "keep reading events file as it grows until END marker or SIGINT")
+	lateness := flag.Duration("lateness", 0, "hold events for `window` to put late ones in time order")
+	replaySpeed := flag.Float64("replay", 0, "replay events in wall-clock time sped up `times`, controlled from stdin")
+	timePolicy := flag.String("time-policy", "warn", "what to do with events going back in time: warn, reject or clamp")
+	inFormat := flag.String("format", "", "`format` of events sources: text, json or csv (detected by extension by default)")
+	eventsOut := flag.String("events-out", "", "write processed and generated events to `file`")
+	prior := flag.String("prior", "", "results `file` of the previous race to make pursuit start list from")
+	outFormat := flag.String("out-format", "", "`format` of events-out file: text or json (detected by extension by default)")
+	flag.Usage = func() {
+		fmt.Printf("Usage: %v [flags] EVENTS_SOURCE... CONFIG_FILEPATH\n", os.Args[0])
+		fmt.Println("EVENTS_SOURCE is one of: file:PATH, PATH, - (stdin), tcp:ADDRESS")
+		fmt.Println("Several sources are merged by time, ties are resolved by ?priority=N suffix (lower first)")
+		fmt.Println("Format of a single source can be set by ?format=text|json|csv suffix")
+		fmt.Println("CSV columns are set by ?time=NAME&event=NAME&competitor=NAME&extra=NAME&seq=NAME&comma=semicolon suffix")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() < 2 {
+		flag.Usage()
 		os.Exit(1)
 	}
 
-	eventsFP := os.Args[1]
-	listener, err := biathlon.NewEventListener(os.Args[1])
+	configFP := flag.Arg(flag.NArg() - 1)
+	config, err := biathlon.ParseConfig(configFP)
 	if err != nil {
-		fmt.Printf("Failed to open specified file: %v\n", eventsFP)
+		fmt.Printf("Failed to read config %v:\n%v\n", configFP, err)
+		os.Exit(1)
+	}
+	if err := loadStartList(&config, *prior); err != nil {
+		fmt.Println(err)
 		os.Exit(1)
 	}
 
-	listenerLogFile, err := os.OpenFile("listener.log", os.O_WRONLY|os.O_CREATE, 0o644)
+	opts := sourceOptions{
+		follow: *follow,
+		replay: *replaySpeed != 0,
+		format: *inFormat,
+		date:   config.RaceDate(),
+	}
+	source, err := openSources(flag.Args()[:flag.NArg()-1], opts)
 	if err != nil {
-		fmt.Printf("Failed to open listne log file: %v\n", listenerLogFile)
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	if *replaySpeed > 0 {
+		replay := biathlon.NewReplaySource(source, *replaySpeed)
+		go controlReplay(replay, os.Stdin)
+		source = replay
+	} else if *replaySpeed < 0 {
+		fmt.Println("Replay speed must be positive")
 		os.Exit(1)
 	}
-	defer listenerLogFile.Close()
 
-	listener.SetLogger(log.New(listenerLogFile, "Listener: ", log.Ltime))
+	source = biathlon.NewDedupSource(source)
+	if *lateness > 0 {
+		source = biathlon.NewReorderSource(source, *lateness)
+	}
 
-	configFP := os.Args[2]
-	config, err := biathlon.ParseConfig(configFP)
+	policy, err := biathlon.ParseTimePolicy(*timePolicy)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	source = biathlon.NewMonotonicSource(source, policy)
+
+	listenerLogFile, err := os.OpenFile("listener.log", os.O_WRONLY|os.O_CREATE, 0o644)
 	if err != nil {
-		fmt.Printf("Failed to open specified file: %v\n", configFP)
+		fmt.Printf("Failed to open listne log file: %v\n", listenerLogFile)
 		os.Exit(1)
 	}
+	defer listenerLogFile.Close()
+
+	setSourceLogger(source, log.New(listenerLogFile, "Listener: ", log.Ltime))
+	stopOnSignal(source)
 
 	stats := statistics.New(config)
-	processor := biathlon.NewProcessor(config, listener.Events())
+	processor := biathlon.NewProcessor(config, source)
 	handleStats(processor, stats)
 
 	processorLogFile, err := os.OpenFile("processor.log", os.O_WRONLY|os.O_CREATE, 0o644)
@@ -49,13 +100,48 @@ func main() {
 	}
 	defer processorLogFile.Close()
 
-	processor.SetLogger(biathlon.NewDefaultLogger(processorLogFile))
+	var logger biathlon.Logger = biathlon.NewDefaultLogger(processorLogFile)
+	if *eventsOut != "" {
+		eventsOutFile, err := os.Create(*eventsOut)
+		if err != nil {
+			fmt.Printf("Failed to create events output file: %v\n", *eventsOut)
+			os.Exit(1)
+		}
+		defer eventsOutFile.Close()
+
+		eventsLogger, err := newEventsLogger(eventsOutFile, detectFormat(*eventsOut, *outFormat))
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		logger = biathlon.MultiLogger{logger, eventsLogger}
+	}
+	processor.SetLogger(logger)
 
-	go listener.Start()
 	processor.Start()
 
 	table := stats.GetResults()
-	showReport(table)
+	showReport(config, table, stats.TeamResults(), stats.HitRates())
+}
+
+// loadStartList sets pursuit start list from results of the previous race.
+func loadStartList(config *biathlon.Config, prior string) error {
+	if config.RaceFormat() != biathlon.Pursuit {
+		if prior != "" {
+			return errors.New("previous race results are used only in pursuit")
+		}
+		return nil
+	}
+	if prior == "" {
+		return errors.New("pursuit requires results of the previous race (-prior)")
+	}
+
+	startList, err := statistics.ReadStartList(prior)
+	if err != nil {
+		return err
+	}
+	config.StartList = startList
+	return nil
 }
 
 func handleStats(processor *biathlon.Processor, stats *statistics.Statistics) {
@@ -63,17 +149,46 @@ func handleStats(processor *biathlon.Processor, stats *statistics.Statistics) {
 	processor.Handle(biathlon.BeSheduled, stats.OnBeSheduled)
 	processor.Handle(biathlon.Start, stats.OnStart)
 	processor.Handle(biathlon.HitTarget, stats.OnHitTarget)
-	processor.Handle(biathlon.LeaveFiringRange, stats.OnComeToFiringRange)
+	processor.Handle(biathlon.LoadSpare, stats.OnLoadSpare)
+	processor.Handle(biathlon.ComeToFiringRange, stats.OnComeToFiringRange)
+	processor.Handle(biathlon.LeaveFiringRange, stats.OnLeaveFiringRange)
 	processor.Handle(biathlon.EnterPenaltyLap, stats.OnEnterPenaltyLap)
 	processor.Handle(biathlon.LeavePenaltyLap, stats.OnLeavePenaltyLap)
+	processor.Handle(biathlon.SkipPenaltyLoops, stats.OnSkipPenaltyLoops)
 	processor.Handle(biathlon.EndMainLap, stats.OnEndMainLap)
 	processor.Handle(biathlon.BeUnableToContinue, stats.OnBeUnableToContinue)
 	processor.Handle(biathlon.Disqualify, stats.OnDisqualify)
 	processor.Handle(biathlon.Finish, stats.OnFinish)
+	processor.Handle(biathlon.StartLeg, stats.OnStart)
 }
 
-func showReport(table []statistics.Result) {
+func showReport(
+	config biathlon.Config,
+	table []statistics.Result,
+	teams []statistics.TeamResult,
+	rates map[biathlon.Position]statistics.Shooting,
+) {
+	if date := time.Time(config.Date); !date.IsZero() {
+		fmt.Printf("Race date: %s\n", date.Format(time.DateOnly))
+	}
 	for _, v := range table {
 		fmt.Println(v.String())
 	}
+	if len(teams) > 0 {
+		fmt.Println("Teams:")
+	}
+	for _, team := range teams {
+		fmt.Println(team.String())
+	}
+	for _, pos := range []biathlon.Position{biathlon.Prone, biathlon.Standing} {
+		if rate, ok := rates[pos]; ok && rate.Shots > 0 {
+			fmt.Printf(
+				"Hit rate %s: %.1f%% (%d/%d)\n",
+				pos,
+				100*float64(rate.Hits)/float64(rate.Shots),
+				rate.Hits,
+				rate.Shots,
+			)
+		}
+	}
 }
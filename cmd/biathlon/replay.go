@@ -0,0 +1,63 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Chernovuk/biathlon-competetions/internal/biathlon"
+)
+
+const replayHelp = `Replay commands:
+  pause          pause replay
+  resume         resume replay
+  speed N        replay N times faster than the race
+  seek HH:MM:SS  skip events till the given time
+  pos            show current replay time`
+
+// controlReplay reads replay commands line by line from in.
+// Answers are written to stderr, as stdout is used by the resulting table.
+func controlReplay(replay *biathlon.ReplaySource, in io.Reader) {
+	scanner := bufio.NewScanner(in)
+	for scanner.Scan() {
+		cmd, arg, _ := strings.Cut(strings.TrimSpace(scanner.Text()), " ")
+		arg = strings.TrimSpace(arg)
+
+		switch cmd {
+		case "":
+		case "pause", "p":
+			replay.Pause()
+		case "resume", "r":
+			replay.Resume()
+		case "speed", "x":
+			speed, err := strconv.ParseFloat(arg, 64)
+			if err != nil || speed <= 0 {
+				fmt.Fprintf(os.Stderr, "Invalid replay speed: %q\n", arg)
+				continue
+			}
+			replay.SetSpeed(speed)
+		case "seek", "s":
+			t, err := time.Parse(time.TimeOnly, arg)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Invalid replay time: %q\n", arg)
+				continue
+			}
+			if err := replay.Seek(t); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+			}
+		case "pos":
+			pos := replay.Position()
+			if pos.IsZero() {
+				fmt.Fprintln(os.Stderr, "Replay hasn't started yet")
+				continue
+			}
+			fmt.Fprintln(os.Stderr, pos.Format(time.TimeOnly))
+		default:
+			fmt.Fprintln(os.Stderr, replayHelp)
+		}
+	}
+}
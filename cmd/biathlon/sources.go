@@ -0,0 +1,253 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"net/url"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/Chernovuk/biathlon-competetions/internal/biathlon"
+)
+
+const (
+	formatText = "text"
+	formatJSON = "json"
+	formatCSV  = "csv"
+)
+
+type sourceOptions struct {
+	follow bool
+	replay bool
+	format string
+	// date is a race date for timestamps given without one.
+	date time.Time
+}
+
+// openSources opens every source and merges them if there are several ones.
+// Dates of every source are resolved separately before merging.
+func openSources(uris []string, opts sourceOptions) (biathlon.EventSource, error) {
+	if opts.replay && slices.Contains(uris, "-") {
+		return nil, fmt.Errorf("stdin can't be used as events source while replay is controlled from it")
+	}
+
+	if len(uris) == 1 {
+		uri, query, err := splitQuery(uris[0])
+		if err != nil {
+			return nil, err
+		}
+		source, err := openSource(uri, query, opts)
+		if err != nil {
+			return nil, err
+		}
+		return biathlon.NewDateResolver(source, opts.date), nil
+	}
+
+	merge := biathlon.NewMergeSource()
+	for _, rawURI := range uris {
+		uri, query, err := splitQuery(rawURI)
+		if err != nil {
+			return nil, err
+		}
+
+		priority := 0
+		if p := query.Get("priority"); p != "" {
+			priority, err = strconv.Atoi(p)
+			if err != nil {
+				return nil, fmt.Errorf("invalid priority of %v: %w", uri, err)
+			}
+		}
+
+		source, err := openSource(uri, query, opts)
+		if err != nil {
+			return nil, err
+		}
+		merge.Add(biathlon.NewDateResolver(source, opts.date), priority)
+	}
+	return merge, nil
+}
+
+// splitQuery separates source options given after "?" from source URI.
+func splitQuery(rawURI string) (string, url.Values, error) {
+	uri, rawQuery, _ := strings.Cut(rawURI, "?")
+	query, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return "", nil, fmt.Errorf("invalid options of %v: %w", uri, err)
+	}
+	return uri, query, nil
+}
+
+// openSource chooses event source by URI-like argument:
+// "-" is stdin, "tcp:ADDRESS" is TCP listener, "file:PATH" or just PATH is file.
+func openSource(uri string, query url.Values, opts sourceOptions) (biathlon.EventSource, error) {
+	format := opts.format
+	if f := query.Get("format"); f != "" {
+		format = f
+	}
+
+	switch {
+	case uri == "-":
+		parser, err := lineParser(detectFormat("", format))
+		if err != nil {
+			return nil, err
+		}
+		stdin := biathlon.NewStdinSource()
+		stdin.SetParser(parser)
+		return stdin, nil
+	case strings.HasPrefix(uri, "tcp:"):
+		parser, err := lineParser(detectFormat("", format))
+		if err != nil {
+			return nil, err
+		}
+		tcpListener, err := biathlon.NewTCPListener(strings.TrimPrefix(uri, "tcp:"))
+		if err != nil {
+			return nil, fmt.Errorf("failed to listen on %v: %w", uri, err)
+		}
+		tcpListener.SetParser(parser)
+		return tcpListener, nil
+	default:
+		path := strings.TrimPrefix(uri, "file:")
+		if detectFormat(path, format) == formatCSV {
+			return openCSVSource(path, query)
+		}
+
+		parser, err := lineParser(detectFormat(path, format))
+		if err != nil {
+			return nil, err
+		}
+		listener, err := biathlon.NewEventListener(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open specified file %v: %w", uri, err)
+		}
+		listener.SetParser(parser)
+		listener.SetFollow(opts.follow)
+		return listener, nil
+	}
+}
+
+// detectFormat returns forced format if it's set
+// or guesses it by extension of path otherwise.
+func detectFormat(path, forced string) string {
+	if forced != "" {
+		return forced
+	}
+
+	switch filepath.Ext(path) {
+	case ".jsonl", ".ndjson", ".json":
+		return formatJSON
+	case ".csv":
+		return formatCSV
+	default:
+		return formatText
+	}
+}
+
+// openCSVSource reads CSV file with columns names overridden by time,
+// event, competitor, extra, seq options and delimiter set by comma option.
+// As ";" can't be used in options, it's written as "semicolon".
+func openCSVSource(path string, query url.Values) (biathlon.EventSource, error) {
+	mapping := biathlon.DefaultCSVMapping
+	for key, column := range map[string]*string{
+		"time":       &mapping.Time,
+		"event":      &mapping.EventID,
+		"competitor": &mapping.Competitor,
+		"extra":      &mapping.Extra,
+		"seq":        &mapping.Seq,
+	} {
+		if name := query.Get(key); name != "" {
+			*column = name
+		}
+	}
+	switch comma := query.Get("comma"); comma {
+	case "":
+	case "semicolon":
+		mapping.Comma = ';'
+	case "tab":
+		mapping.Comma = '\t'
+	default:
+		mapping.Comma = []rune(comma)[0]
+	}
+
+	source, err := biathlon.NewCSVSource(path, mapping)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open specified file %v: %w", path, err)
+	}
+	return source, nil
+}
+
+func lineParser(format string) (biathlon.LineParser, error) {
+	switch format {
+	case formatText:
+		return biathlon.ParseEvent, nil
+	case formatJSON:
+		return biathlon.ParseEventJSON, nil
+	default:
+		return nil, fmt.Errorf("unknown events format: %v", format)
+	}
+}
+
+func newEventsLogger(out io.Writer, format string) (biathlon.Logger, error) {
+	switch format {
+	case formatText:
+		return biathlon.NewCanonicalLogger(out), nil
+	case formatJSON:
+		return biathlon.NewJSONLogger(out), nil
+	default:
+		return nil, fmt.Errorf("unsupported events output format: %v", format)
+	}
+}
+
+// setSourceLogger sets logger of source and of all sources wrapped by it.
+func setSourceLogger(source biathlon.EventSource, logger *log.Logger) {
+	switch s := source.(type) {
+	case interface{ SetLogger(*log.Logger) }:
+		s.SetLogger(logger)
+	case interface{ Source() biathlon.EventSource }:
+		setSourceLogger(s.Source(), logger)
+	case interface{ Sources() []biathlon.EventSource }:
+		for _, wrapped := range s.Sources() {
+			setSourceLogger(wrapped, logger)
+		}
+	}
+}
+
+// stopOnSignal stops source on SIGINT or SIGTERM, so that the race
+// can be finalized when events file is followed or received over TCP.
+// First signal stops only inputs, so events read by then are still
+// merged and processed. Second one stops the whole source right away.
+func stopOnSignal(source biathlon.EventSource) {
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-sig
+		stopInputs(source)
+		<-sig
+		source.Stop()
+	}()
+}
+
+// stopInputs stops sources reading events, sources wrapping
+// them finish by themselves when their inputs are exhausted.
+// Replay stands for the race feed, so it's stopped as a whole:
+// events it hasn't replayed yet haven't come.
+func stopInputs(source biathlon.EventSource) {
+	switch s := source.(type) {
+	case *biathlon.ReplaySource:
+		s.Stop()
+	case interface{ Source() biathlon.EventSource }:
+		stopInputs(s.Source())
+	case interface{ Sources() []biathlon.EventSource }:
+		for _, wrapped := range s.Sources() {
+			stopInputs(wrapped)
+		}
+	default:
+		source.Stop()
+	}
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/Chernovuk/biathlon-competetions/internal/biathlon"
+	"github.com/Chernovuk/biathlon-competetions/internal/simulator"
+	"github.com/Chernovuk/biathlon-competetions/internal/statistics"
+)
+
+func main() {
+	params := simulator.Params{}
+	flag.IntVar(&params.Competitors, "competitors", 30, "number of competitors")
+	flag.Float64Var(&params.SpeedMean, "speed", 6.5, "mean ski speed of competitors, m/s")
+	flag.Float64Var(&params.SpeedStdDev, "speed-sd", 0.4, "standard deviation of ski speed, m/s")
+	flag.Float64Var(&params.Accuracy, "accuracy", 0.85, "probability to hit a target")
+	flag.Float64Var(&params.DNFProb, "dnf", 0.05, "probability that competitor can't finish")
+	flag.Float64Var(&params.LateStartProb, "late", 0.03, "probability that competitor starts late")
+	flag.Uint64Var(&params.Seed, "seed", 1, "random seed, the same seed gives the same race")
+	prior := flag.String("prior", "", "results `file` of the previous race to make pursuit start list from")
+	out := flag.String("o", "", "write events to `file` instead of stdout")
+	flag.Usage = func() {
+		fmt.Printf("Usage: %v [flags] CONFIG_FILEPATH\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	configFP := flag.Arg(0)
+	config, err := biathlon.ParseConfig(configFP)
+	if err != nil {
+		fmt.Printf("Failed to read config %v:\n%v\n", configFP, err)
+		os.Exit(1)
+	}
+
+	if *prior != "" {
+		config.StartList, err = statistics.ReadStartList(*prior)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+	}
+
+	events, err := simulator.Simulate(config, params)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	output := os.Stdout
+	if *out != "" {
+		output, err = os.Create(*out)
+		if err != nil {
+			fmt.Printf("Failed to create output file: %v\n", *out)
+			os.Exit(1)
+		}
+		defer output.Close()
+	}
+
+	w := bufio.NewWriter(output)
+	defer w.Flush()
+	for _, e := range events {
+		fmt.Fprintln(w, biathlon.FormatEvent(e))
+	}
+}
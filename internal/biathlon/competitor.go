@@ -16,6 +16,8 @@ const (
 	NotStarted
 	Disqualified
 	CannotContinue
+	// Relayed competitor has finished the leg and tagged over the next one.
+	Relayed
 )
 
 type CompetitorState struct {
@@ -25,5 +27,14 @@ type CompetitorState struct {
 	ScheduledStartTime time.Time
 	ActualStartTime    time.Time
 	VisitedRanges      []bool
-	HitsThisRange      [5]bool
+	CurrentRange       int
+	HitsThisRange      []bool
+	SparesThisRange    int
+	// LoopsOwed is number of penalty loops for targets left standing
+	// which competitor hasn't skied yet.
+	LoopsOwed    int
+	PenaltyEntry time.Time
+	// Team and Leg of the competitor in relay.
+	Team string
+	Leg  int
 }
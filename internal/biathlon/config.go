@@ -1,24 +1,281 @@
 package biathlon
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
+	"reflect"
+	"slices"
 	"strings"
 	"time"
 )
 
+// DefaultShotsPerRange is number of shots on every range if config doesn't set it.
+const DefaultShotsPerRange = 5
+
+// DefaultPenaltyTime is penalty for every missed target in Individual format
+// if config doesn't set it.
+const DefaultPenaltyTime = time.Minute
+
+// DefaultStartTolerance is how far from the scheduled time competitor
+// may start in formats without a draw if config doesn't set it.
+const DefaultStartTolerance = time.Second
+
+// DefaultMaxPenaltySpeed is the fastest speed in m/s penalty loops can be
+// skied at if config doesn't set it. It's an upper bound: the best skiers
+// go round the 150 m loop in about 23 seconds, that is 6.5 m/s. Loops
+// which would need a faster one are considered skipped.
+const DefaultMaxPenaltySpeed = 7.0
+
+// DefaultSkippedLoopPenalty is time added for every skipped penalty loop
+// if config doesn't set it.
+const DefaultSkippedLoopPenalty = 2 * time.Minute
+
 // Config structure represents configuration
 // that can be read from json file.
 type Config struct {
-	Laps        int      `json:"laps"`
-	LapLen      float64  `json:"lapLen"`
-	PenaltyLen  float64  `json:"penaltyLen"`
-	FiringLines int      `json:"firingLines"`
-	Start       justTime `json:"start"`
-	StartDelta  duration `json:"startDelta"`
+	// Format of the race, Sprint if it's not set.
+	Format     RaceFormat         `json:"format"`
+	Laps       int                `json:"laps"`
+	LapLen     oneOrMany[float64] `json:"lapLen"`
+	PenaltyLen float64            `json:"penaltyLen"`
+	// MaxPenaltySpeed is the fastest speed penalty loops can be skied at.
+	// DefaultMaxPenaltySpeed if it's not set.
+	MaxPenaltySpeed float64 `json:"maxPenaltySpeed"`
+	// PenaltySanction is applied for skipped penalty loops: time penalty
+	// of SkippedLoopPenalty for every loop or disqualification.
+	PenaltySanction    Sanction `json:"penaltySanction"`
+	SkippedLoopPenalty duration `json:"skippedLoopPenalty"`
+	// PenaltyTime is added for every miss instead of penalty lap
+	// in Individual format. DefaultPenaltyTime if it's not set.
+	PenaltyTime   duration       `json:"penaltyTime"`
+	FiringLines   int            `json:"firingLines"`
+	ShotsPerRange oneOrMany[int] `json:"shotsPerRange"`
+	// SpareRounds is number of hand-loaded rounds competitor may use
+	// on every range after the main ones, e.g. 3 in relay.
+	SpareRounds int `json:"spareRounds"`
+	// RangeSchedule prescribes range and position of shooting on each lap.
+	// Ranges may be visited in any order if it's empty.
+	RangeSchedule []RangeSlot `json:"rangeSchedule"`
+	Date          justDate    `json:"date"`
+	Start         justTime    `json:"start"`
+	StartDelta    duration    `json:"startDelta"`
+	// StartTolerance is how far from the scheduled time competitor may start
+	// in formats without a draw. DefaultStartTolerance if it's not set.
+	StartTolerance duration `json:"startTolerance"`
+
+	// Teams of relay with their members in order of legs.
+	Teams []Team `json:"teams"`
+
+	// StartList is time behind the first starter for every competitor
+	// of Pursuit. It comes from results of the previous race, not from config file.
+	StartList map[int]time.Duration `json:"-"`
+}
+
+// RaceFormat returns format of the race.
+func (c Config) RaceFormat() RaceFormat {
+	if c.Format == "" {
+		return Sprint
+	}
+	return c.Format
 }
 
+// MissPenalty returns time added for every missed target. It's zero
+// if misses are punished by penalty laps.
+func (c Config) MissPenalty() time.Duration {
+	switch {
+	case c.RaceFormat() != Individual:
+		return 0
+	case c.PenaltyTime == 0:
+		return DefaultPenaltyTime
+	default:
+		return time.Duration(c.PenaltyTime)
+	}
+}
+
+// PenaltySpeedLimit returns the fastest speed penalty loops can be skied at.
+func (c Config) PenaltySpeedLimit() float64 {
+	if c.MaxPenaltySpeed == 0 {
+		return DefaultMaxPenaltySpeed
+	}
+	return c.MaxPenaltySpeed
+}
+
+// LoopsSkied returns the most penalty loops competitor
+// could ski in the penalty area during d.
+func (c Config) LoopsSkied(d time.Duration) int {
+	return int(d.Seconds() * c.PenaltySpeedLimit() / c.PenaltyLen)
+}
+
+// SkipPenalty returns time added for every skipped penalty loop. It's zero
+// if competitor is disqualified for skipping them.
+func (c Config) SkipPenalty() time.Duration {
+	switch {
+	case c.PenaltySanction == DisqualifySanction:
+		return 0
+	case c.SkippedLoopPenalty == 0:
+		return DefaultSkippedLoopPenalty
+	default:
+		return time.Duration(c.SkippedLoopPenalty)
+	}
+}
+
+// StartWindow returns the earliest and the latest time competitor
+// scheduled at the given time may start. Earliest is zero
+// if competitor may start at any time before the latest.
+func (c Config) StartWindow(scheduled time.Time) (time.Time, time.Time) {
+	if c.RaceFormat().Drawn() {
+		return time.Time{}, scheduled.Add(time.Duration(c.StartDelta))
+	}
+
+	tolerance := time.Duration(c.StartTolerance)
+	if tolerance == 0 {
+		tolerance = DefaultStartTolerance
+	}
+	return scheduled.Add(-tolerance), scheduled.Add(tolerance)
+}
+
+// LapLength returns length of the lap with the given 1-based number.
+func (c Config) LapLength(lap int) float64 {
+	return c.LapLen.at(lap)
+}
+
+// ShotsAt returns number of shots on the firing range with the given number.
+func (c Config) ShotsAt(firingRange int) int {
+	if len(c.ShotsPerRange) == 0 {
+		return DefaultShotsPerRange
+	}
+	return c.ShotsPerRange.at(firingRange)
+}
+
+// ScheduledRange returns range slot prescribed for the lap
+// with the given number. It's false if competitor doesn't shoot on that lap.
+func (c Config) ScheduledRange(lap int) (RangeSlot, bool) {
+	for _, slot := range c.RangeSchedule {
+		if slot.Lap == lap {
+			return slot, true
+		}
+	}
+	return RangeSlot{}, false
+}
+
+// RangePosition returns shooting position on the firing range with the given
+// number or empty Position if schedule doesn't set it.
+func (c Config) RangePosition(firingRange int) Position {
+	for _, slot := range c.RangeSchedule {
+		if slot.Range == firingRange {
+			return slot.Position
+		}
+	}
+	return ""
+}
+
+// RaceDate returns the date of the race. If it's not configured,
+// date of timestamps without date is returned.
+func (c Config) RaceDate() time.Time {
+	if time.Time(c.Date).IsZero() {
+		return time.Date(0, time.January, 1, 0, 0, 0, 0, time.UTC)
+	}
+	return time.Time(c.Date)
+}
+
+// StartTime returns planned start time of the first competitor on race date.
+func (c Config) StartTime() time.Time {
+	return onDate(c.RaceDate(), time.Time(c.Start))
+}
+
+// RaceFormat tells how competitors start and how misses are punished.
+type RaceFormat string
+
+const (
+	// Sprint competitors start one by one and ski a penalty lap for every miss.
+	Sprint RaceFormat = "sprint"
+	// Individual competitors start one by one and get penalty time for every miss.
+	Individual RaceFormat = "individual"
+	// Pursuit competitors start in order and with gaps of the previous race results.
+	Pursuit RaceFormat = "pursuit"
+	// MassStart competitors start all at once.
+	MassStart RaceFormat = "massStart"
+	// Relay teams start all at once and their members ski legs one after another.
+	Relay RaceFormat = "relay"
+)
+
+// Drawn reports whether start times are set by a draw
+// and competitors start one after another with StartDelta interval.
+func (f RaceFormat) Drawn() bool {
+	return f == Sprint || f == Individual
+}
+
+// RankedByFinish reports whether finish order is the ranking, so results
+// are counted from the start of the race rather than from the start of competitor.
+func (f RaceFormat) RankedByFinish() bool {
+	return f == Pursuit || f == MassStart
+}
+
+// Sanction for skipped penalty loops.
+type Sanction string
+
+const (
+	TimeSanction       Sanction = "time"
+	DisqualifySanction Sanction = "disqualify"
+)
+
+// Team of relay. Members are competitor ids in order of legs.
+type Team struct {
+	Name    string `json:"name"`
+	Members []int  `json:"members"`
+}
+
+// TeamLeg returns relay team of the competitor
+// and the 1-based number of the leg they ski.
+func (c Config) TeamLeg(id int) (Team, int, bool) {
+	for _, team := range c.Teams {
+		if i := slices.Index(team.Members, id); i >= 0 {
+			return team, i + 1, true
+		}
+	}
+	return Team{}, 0, false
+}
+
+// Position is a shooting position on the firing range.
+type Position string
+
+const (
+	Prone    Position = "prone"
+	Standing Position = "standing"
+)
+
+// RangeSlot tells that competitor shoots on the range
+// in the position during the lap.
+type RangeSlot struct {
+	Lap      int      `json:"lap"`
+	Range    int      `json:"range"`
+	Position Position `json:"position"`
+}
+
+// oneOrMany holds either a single value used for every lap (or range)
+// or a list of values for each of them.
+type oneOrMany[T int | float64] []T
+
+// at returns value for the 1-based index i.
+func (o oneOrMany[T]) at(i int) T {
+	switch {
+	case len(o) == 0:
+		return 0
+	case len(o) == 1:
+		return o[0]
+	case i < 1 || i > len(o):
+		return 0
+	default:
+		return o[i-1]
+	}
+}
+
+// justDate represents date of the race without time.
+type justDate time.Time
+
 // justTime represents time.Time without date parameters
 // to satisfy start time from config.json.
 type justTime time.Time
@@ -27,22 +284,60 @@ type justTime time.Time
 // startDelta time as a correct duration parameter.
 type duration time.Duration
 
-// ParseConfig converts json data into Config struct.
+// ParseConfig converts json data into Config struct and validates it.
+// Unknown fields and values of wrong types are reported as errors
+// together with problems found by Validate.
 func ParseConfig(filePath string) (Config, error) {
 	configFile, err := os.ReadFile(filePath)
 	if err != nil {
 		return Config{}, fmt.Errorf("failed to open config file: %w", err)
 	}
 
-	settings := Config{}
-	err = json.Unmarshal(configFile, &settings)
+	var syntax any
+	err = json.Unmarshal(configFile, &syntax)
 	if err != nil {
 		return Config{}, fmt.Errorf("failed to parse config data: %w", err)
 	}
 
+	settings := Config{}
+	errs := decodeConfig("$", bytes.TrimSpace(configFile), reflect.ValueOf(&settings).Elem())
+	errs = append(errs, withoutPaths(settings.Validate(), errs)...)
+	if err := errors.Join(errs...); err != nil {
+		return Config{}, err
+	}
+
 	return settings, nil
 }
 
+func (o *oneOrMany[T]) UnmarshalJSON(b []byte) error {
+	var single T
+	if err := json.Unmarshal(b, &single); err == nil {
+		*o = oneOrMany[T]{single}
+		return nil
+	}
+
+	var many []T
+	if err := json.Unmarshal(b, &many); err != nil {
+		return fmt.Errorf("either a number or a list of numbers expected: %w", err)
+	}
+	*o = many
+	return nil
+}
+
+func (d *justDate) UnmarshalJSON(b []byte) error {
+	value := strings.Trim(string(b), `"`)
+	if value == "" || value == "null" {
+		return nil
+	}
+
+	t, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		return err
+	}
+	*d = justDate(t)
+	return nil
+}
+
 func (c *justTime) UnmarshalJSON(b []byte) error {
 	value := strings.Trim(string(b), `"`)
 	if value == "" || value == "null" {
@@ -0,0 +1,192 @@
+package biathlon
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// CSVMapping names header columns holding event fields.
+// Extra and Seq columns are optional, the rest are required.
+type CSVMapping struct {
+	Time       string
+	EventID    string
+	Competitor string
+	Extra      string
+	Seq        string
+
+	// Comma is a field delimiter, ',' is used if it's not set.
+	Comma rune
+}
+
+var DefaultCSVMapping = CSVMapping{
+	Time:       "time",
+	EventID:    "event",
+	Competitor: "competitor",
+	Extra:      "extra",
+	Seq:        "seq",
+}
+
+// CSVRowError reports a row of CSV file that can't be converted into Event.
+// Row is a line of the file where the record starts, so it matches the row
+// of spreadsheet even after blank lines and quoted fields spanning lines.
+type CSVRowError struct {
+	Row int
+	Err error
+}
+
+func (e *CSVRowError) Error() string {
+	return fmt.Sprintf("row %d: %v", e.Row, e.Err)
+}
+
+func (e *CSVRowError) Unwrap() error {
+	return e.Err
+}
+
+// CSVSource reads events exported from spreadsheets as CSV with header row.
+type CSVSource struct {
+	stream
+	file     *os.File
+	filepath string
+	reader   *csv.Reader
+
+	// columns are indexes of time, event id, competitor, extra and seq columns.
+	// Index of optional column is -1 if there is no such column.
+	columns [5]int
+}
+
+func NewCSVSource(filepath string, mapping CSVMapping) (*CSVSource, error) {
+	f, err := os.Open(filepath)
+	if err != nil {
+		return &CSVSource{}, err
+	}
+
+	reader := csv.NewReader(f)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	if mapping.Comma != 0 {
+		reader.Comma = mapping.Comma
+	}
+
+	header, err := reader.Read()
+	if err != nil {
+		f.Close()
+		return &CSVSource{}, fmt.Errorf("failed to read header of %s: %w", filepath, err)
+	}
+
+	columns, err := resolveColumns(header, mapping)
+	if err != nil {
+		f.Close()
+		return &CSVSource{}, fmt.Errorf("%s: %w", filepath, err)
+	}
+
+	return &CSVSource{
+		stream:   newStream(),
+		file:     f,
+		filepath: filepath,
+		reader:   reader,
+		columns:  columns,
+	}, nil
+}
+
+func resolveColumns(header []string, mapping CSVMapping) ([5]int, error) {
+	columns := [5]int{-1, -1, -1, -1, -1}
+	names := [5]string{mapping.Time, mapping.EventID, mapping.Competitor, mapping.Extra, mapping.Seq}
+
+	for i, name := range names {
+		for j, title := range header {
+			if name != "" && strings.EqualFold(strings.TrimSpace(title), name) {
+				columns[i] = j
+				break
+			}
+		}
+	}
+
+	var errs []error
+	for i, name := range names[:3] {
+		if columns[i] == -1 {
+			errs = append(errs, fmt.Errorf("no %q column in header", name))
+		}
+	}
+
+	return columns, errors.Join(errs...)
+}
+
+func (c *CSVSource) Name() string {
+	return c.filepath
+}
+
+func (c *CSVSource) Start() {
+	defer c.close()
+	defer func() {
+		if err := c.file.Close(); err != nil {
+			c.fail(fmt.Errorf("%s: %w", c.filepath, err))
+		}
+	}()
+
+	for {
+		record, err := c.reader.Read()
+		if err == io.EOF {
+			return
+		}
+
+		var row int
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			// Line of the error is reported by CSVRowError.
+			row, err = parseErr.StartLine, fmt.Errorf("column %d: %w", parseErr.Column, parseErr.Err)
+		} else if err != nil {
+			c.fail(fmt.Errorf("%s: %w", c.filepath, err))
+			return
+		} else {
+			row, _ = c.reader.FieldPos(0)
+		}
+
+		if err == nil {
+			var event Event
+			event, err = c.eventFromRecord(record)
+			if err == nil {
+				event.Source = c.filepath
+				if !c.send(event) {
+					return
+				}
+				continue
+			}
+		}
+
+		rowErr := &CSVRowError{Row: row, Err: err}
+		if !c.fail(fmt.Errorf("%s: %w", c.filepath, rowErr)) {
+			return
+		}
+	}
+}
+
+func (c *CSVSource) eventFromRecord(record []string) (Event, error) {
+	// Tokens of missing columns are left empty
+	// and their column points right after the last field.
+	var tokens [5]token
+	for i, col := range c.columns {
+		if col == -1 || col >= len(record) {
+			tokens[i] = token{column: len(record) + 1}
+			continue
+		}
+		tokens[i] = token{text: strings.TrimSpace(record[col]), column: col + 1}
+	}
+
+	event, err := eventFromTokens(tokens[0], tokens[1], tokens[2], tokens[3])
+	if err != nil {
+		return Event{}, err
+	}
+
+	if seq := tokens[4]; seq.text != "" {
+		event.Seq, err = strconv.Atoi(seq.text)
+		if err != nil || event.Seq < 1 {
+			return Event{}, unexpected(seq, "sequence number", err)
+		}
+	}
+	return event, nil
+}
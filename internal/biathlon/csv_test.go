@@ -0,0 +1,83 @@
+package biathlon
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeCSV(t *testing.T, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "events.csv")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestCSVSource(t *testing.T) {
+	path := writeCSV(t, strings.Join([]string{
+		"Competitor;Time;Event;Comment",
+		"1;09:05:59.867;1;",
+		"",
+		"1;09:15:00.841;2;09:30:00.000",
+		`1;09:59:03.872;11;"Lost in`,
+		`the forest"`,
+		"1;09:59:05.000;one;",
+		`1;"09:59:06.000;1`,
+	}, "\n"))
+
+	source, err := NewCSVSource(path, CSVMapping{
+		Time:       "time",
+		EventID:    "event",
+		Competitor: "competitor",
+		Extra:      "comment",
+		Comma:      ';',
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	events, errs := collect(source)
+
+	want := []eventType{Register, BeSheduled, BeUnableToContinue}
+	if len(events) != len(want) {
+		t.Fatalf("events = %+v, want %d events", events, len(want))
+	}
+	for i, e := range events {
+		if e.Type != want[i] || e.CompetitorID != 1 {
+			t.Errorf("event %d = %+v, want type %d", i, e, want[i])
+		}
+	}
+
+	// Rows are lines where records start.
+	wantRows := []int{7, 8}
+	if len(errs) != len(wantRows) {
+		t.Fatalf("errs = %v, want %d errors", errs, len(wantRows))
+	}
+	for i, err := range errs {
+		var rowErr *CSVRowError
+		if !errors.As(err, &rowErr) || rowErr.Row != wantRows[i] {
+			t.Errorf("error %d = %v, want row %d", i, err, wantRows[i])
+		}
+		if strings.Contains(err.Error(), "line") {
+			t.Errorf("error %d = %q, line is reported twice", i, err)
+		}
+	}
+}
+
+func TestCSVSourceHeader(t *testing.T) {
+	path := writeCSV(t, "time,comment\n09:05:59.867,\n")
+
+	_, err := NewCSVSource(path, DefaultCSVMapping)
+	if err == nil {
+		t.Fatal("NewCSVSource() succeeded without event and competitor columns")
+	}
+	for _, column := range []string{`"event"`, `"competitor"`} {
+		if !strings.Contains(err.Error(), column) {
+			t.Errorf("error %q doesn't mention %s column", err, column)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package biathlon
+
+import "time"
+
+// midnightThreshold is how far back time of day must jump
+// to be treated as passing midnight rather than as a late event.
+const midnightThreshold = 12 * time.Hour
+
+// hasDate reports whether timestamp was given with date.
+// Zero time isn't a timestamp at all, e.g. it's time of errors
+// reported before the first event.
+func hasDate(t time.Time) bool {
+	return t.Year() != 0 && !t.IsZero()
+}
+
+// onDate moves time of day t to the given date.
+func onDate(date, t time.Time) time.Time {
+	return time.Date(
+		date.Year(), date.Month(), date.Day(),
+		t.Hour(), t.Minute(), t.Second(), t.Nanosecond(),
+		time.UTC,
+	)
+}
+
+// DateResolver places timestamps given without date on the race date.
+// When time of day goes backwards by more than 12 hours, it assumes
+// that midnight has passed and moves to the next day. When it goes
+// forward by more than 12 hours, the event is a late one from the previous
+// day. Timestamps with date are kept as they are and set the current day
+// for the following ones.
+type DateResolver struct {
+	wrapper
+
+	day     time.Time
+	last    time.Time
+	started bool
+}
+
+func NewDateResolver(source EventSource, date time.Time) *DateResolver {
+	return &DateResolver{
+		wrapper: newWrapper(source),
+		day:     date,
+	}
+}
+
+func (d *DateResolver) Start() {
+	d.pipe(func(e Event) bool {
+		return d.send(d.resolve(e))
+	}, nil, nil)
+}
+
+func (d *DateResolver) resolve(e Event) Event {
+	ts := e.TimeStamp
+	if hasDate(ts) {
+		d.day = onDate(ts, time.Time{})
+	} else {
+		ts = onDate(d.day, ts)
+		if d.started && ts.Before(d.last.Add(-midnightThreshold)) {
+			d.day = d.day.AddDate(0, 0, 1)
+			ts = ts.AddDate(0, 0, 1)
+		} else if d.started && ts.After(d.last.Add(midnightThreshold)) {
+			// Event from before midnight arrived late, the day stays the same.
+			ts = ts.AddDate(0, 0, -1)
+		}
+	}
+
+	if !d.started || ts.After(d.last) {
+		d.last = ts
+		d.started = true
+	}
+	e.TimeStamp = ts
+
+	// Start time set by a draw is the nearest one not far before the draw.
+	if e.Type == BeSheduled {
+		startTime := e.ExtraParams[0].(time.Time)
+		if !hasDate(startTime) {
+			startTime = onDate(ts, startTime)
+			if startTime.Before(ts.Add(-midnightThreshold)) {
+				startTime = startTime.AddDate(0, 0, 1)
+			}
+		}
+		e.ExtraParams = []any{startTime}
+	}
+
+	return e
+}
@@ -0,0 +1,178 @@
+package biathlon
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDateResolverResolve(t *testing.T) {
+	date := time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name  string
+		times []string
+		want  []string
+	}{
+		{
+			name:  "same day",
+			times: []string{"09:00:00", "09:30:00", "09:15:00"},
+			want: []string{
+				"2025-02-01T09:00:00",
+				"2025-02-01T09:30:00",
+				"2025-02-01T09:15:00",
+			},
+		},
+		{
+			name:  "midnight",
+			times: []string{"23:59:58", "00:00:01", "00:10:00"},
+			want: []string{
+				"2025-02-01T23:59:58",
+				"2025-02-02T00:00:01",
+				"2025-02-02T00:10:00",
+			},
+		},
+		{
+			name:  "late event across midnight",
+			times: []string{"23:59:58", "00:00:01", "23:59:59", "00:00:05"},
+			want: []string{
+				"2025-02-01T23:59:58",
+				"2025-02-02T00:00:01",
+				"2025-02-01T23:59:59",
+				"2025-02-02T00:00:05",
+			},
+		},
+		{
+			name:  "timestamp with date",
+			times: []string{"2025-02-03T10:00:00", "10:05:00", "09:59:00"},
+			want: []string{
+				"2025-02-03T10:00:00",
+				"2025-02-03T10:05:00",
+				"2025-02-03T09:59:00",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := NewDateResolver(NewSliceSource(tt.name, nil), date)
+			for i, raw := range tt.times {
+				ts, err := parseEventTime(raw)
+				if err != nil {
+					t.Fatalf("parseEventTime(%q): %v", raw, err)
+				}
+
+				got := d.resolve(Event{TimeStamp: ts, Type: Register}).TimeStamp
+				want, err := time.Parse("2006-01-02T15:04:05", tt.want[i])
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !got.Equal(want) {
+					t.Errorf("event %d (%s): got %v, want %v", i+1, raw, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestDateResolverStartTime(t *testing.T) {
+	date := time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)
+	d := NewDateResolver(NewSliceSource("draw", nil), date)
+
+	ts := time.Date(0, 1, 1, 23, 50, 0, 0, time.UTC)
+	startTime := time.Date(0, 1, 1, 0, 5, 0, 0, time.UTC)
+	e := d.resolve(Event{TimeStamp: ts, Type: BeSheduled, ExtraParams: []any{startTime}})
+
+	want := time.Date(2025, 2, 2, 0, 5, 0, 0, time.UTC)
+	if got := e.ExtraParams[0].(time.Time); !got.Equal(want) {
+		t.Errorf("start time: got %v, want %v", got, want)
+	}
+}
+
+func TestParseEventTime(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want time.Time
+	}{
+		{"[09:05:59.867]", time.Date(0, 1, 1, 9, 5, 59, 867e6, time.UTC)},
+		{"[09:05:59]", time.Date(0, 1, 1, 9, 5, 59, 0, time.UTC)},
+		{"[2025-02-01T09:05:59.867]", time.Date(2025, 2, 1, 9, 5, 59, 867e6, time.UTC)},
+		{"[2025-02-01T09:05:59]", time.Date(2025, 2, 1, 9, 5, 59, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		got, err := parseEventTime(tt.raw)
+		if err != nil {
+			t.Errorf("parseEventTime(%q): %v", tt.raw, err)
+			continue
+		}
+		if !got.Equal(tt.want) {
+			t.Errorf("parseEventTime(%q) = %v, want %v", tt.raw, got, tt.want)
+		}
+	}
+}
+
+func TestFormatEventTime(t *testing.T) {
+	tests := []struct {
+		t    time.Time
+		want string
+	}{
+		{at(9, 5, 59, 867), "09:05:59.867"},
+		{time.Date(2025, 2, 1, 9, 5, 59, 0, time.UTC), "2025-02-01T09:05:59.000"},
+		{time.Time{}, "00:00:00.000"},
+	}
+
+	for _, tt := range tests {
+		if got := formatEventTime(tt.t); got != tt.want {
+			t.Errorf("formatEventTime(%v) = %q, want %q", tt.t, got, tt.want)
+		}
+	}
+}
+
+func TestDefaultLoggerErrorTime(t *testing.T) {
+	var out bytes.Buffer
+	log := NewDefaultLogger(&out)
+
+	// Errors before the first event have no time to be printed with.
+	log.Error(time.Time{}, errors.New("first"))
+	log.Error(time.Date(2025, 2, 1, 9, 5, 59, 867e6, time.UTC), errors.New("second"))
+
+	want := "first\n[2025-02-01T09:05:59.867] second\n"
+	if out.String() != want {
+		t.Errorf("logged %q, want %q", out.String(), want)
+	}
+}
+
+func TestDateResolverSource(t *testing.T) {
+	input := strings.Join([]string{
+		"bad line",
+		"[23:59:58.000] 1 1",
+		"[00:00:01.000] 1 2",
+	}, "\n")
+	date := time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)
+	source := NewDateResolver(NewReaderSource("input", strings.NewReader(input)), date)
+
+	expectLines(t, processLines(t, Config{}, source), []string{
+		"error: input: line 1, ",
+		"[2025-02-01T23:59:58.000] The competitor(1) registered",
+		"[2025-02-02T00:00:01.000] The competitor(2) registered",
+	})
+}
+
+func TestConfigRaceDate(t *testing.T) {
+	conf := Config{Start: justTime(at(9, 30, 0, 0))}
+	if got := conf.StartTime(); !got.Equal(at(9, 30, 0, 0)) {
+		t.Errorf("StartTime() without date = %v", got)
+	}
+
+	if err := json.Unmarshal([]byte(`"2025-02-01"`), &conf.Date); err != nil {
+		t.Fatal(err)
+	}
+	want := time.Date(2025, 2, 1, 9, 30, 0, 0, time.UTC)
+	if got := conf.StartTime(); !got.Equal(want) {
+		t.Errorf("StartTime() = %v, want %v", got, want)
+	}
+}
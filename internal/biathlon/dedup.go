@@ -0,0 +1,148 @@
+package biathlon
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+var (
+	ErrSequenceConflict = errors.New("sequence number is reused by another event")
+	ErrSequenceGap      = errors.New("events are missing")
+)
+
+// DedupSource drops events retransmitted by timing stations. Events with
+// sequence numbers are identified by their source and number, the rest
+// are dropped only if they are exactly the same as some previous one.
+// Gaps in sequence numbers of every source are reported as soon as they
+// are noticed and once again at the end if they weren't filled.
+type DedupSource struct {
+	wrapper
+
+	seen     map[string]Event
+	stations map[string]*station
+}
+
+// station tracks sequence numbers received from one source.
+type station struct {
+	last    int
+	missing map[int]struct{}
+}
+
+func NewDedupSource(source EventSource) *DedupSource {
+	return &DedupSource{
+		wrapper:  newWrapper(source),
+		seen:     make(map[string]Event),
+		stations: make(map[string]*station),
+	}
+}
+
+func (d *DedupSource) Start() {
+	d.pipe(func(e Event) bool {
+		pass, err := d.check(e)
+		if err != nil && !d.fail(&EventError{Event: e, Err: err}) {
+			return false
+		}
+		return !pass || d.send(e)
+	}, nil, d.reportMissing)
+}
+
+// check reports whether event should be passed further.
+func (d *DedupSource) check(e Event) (bool, error) {
+	key := dedupKey(e)
+	if prev, ok := d.seen[key]; ok {
+		if e.Seq != 0 && dedupKey(withoutSeq(prev)) != dedupKey(withoutSeq(e)) {
+			return false, fmt.Errorf(
+				"%w: %s @%d is event %d of competitor(%d), not event %d of competitor(%d)",
+				ErrSequenceConflict,
+				e.Source,
+				e.Seq,
+				prev.Type,
+				prev.CompetitorID,
+				e.Type,
+				e.CompetitorID,
+			)
+		}
+		return false, nil
+	}
+	d.seen[key] = e
+
+	if e.Seq == 0 {
+		return true, nil
+	}
+
+	st, ok := d.stations[e.Source]
+	if !ok {
+		// Numbering of the first received event may start anywhere.
+		d.stations[e.Source] = &station{last: e.Seq, missing: make(map[int]struct{})}
+		return true, nil
+	}
+
+	if e.Seq <= st.last {
+		delete(st.missing, e.Seq)
+		return true, nil
+	}
+
+	from := st.last + 1
+	for seq := from; seq < e.Seq; seq++ {
+		st.missing[seq] = struct{}{}
+	}
+	st.last = e.Seq
+
+	switch from {
+	case e.Seq:
+		return true, nil
+	case e.Seq - 1:
+		return true, fmt.Errorf("%w: %s @%d", ErrSequenceGap, e.Source, from)
+	default:
+		return true, fmt.Errorf("%w: %s @%d..@%d", ErrSequenceGap, e.Source, from, e.Seq-1)
+	}
+}
+
+func (d *DedupSource) reportMissing() {
+	for name, st := range d.stations {
+		if len(st.missing) == 0 {
+			continue
+		}
+
+		missing := make([]int, 0, len(st.missing))
+		for seq := range st.missing {
+			missing = append(missing, seq)
+		}
+		slices.Sort(missing)
+
+		numbers := make([]string, 0, len(missing))
+		for _, seq := range missing {
+			numbers = append(numbers, fmt.Sprintf("@%d", seq))
+		}
+		d.fail(fmt.Errorf(
+			"%w: %s never sent %s",
+			ErrSequenceGap,
+			name,
+			strings.Join(numbers, ", "),
+		))
+	}
+}
+
+// dedupKey identifies sequenced events by source and number
+// and other events by their whole content.
+func dedupKey(e Event) string {
+	if e.Seq != 0 {
+		return fmt.Sprintf("%s@%d", e.Source, e.Seq)
+	}
+	return fmt.Sprintf(
+		"%s|%s|%d|%d|%v",
+		e.Source,
+		e.TimeStamp.Format(time.RFC3339Nano),
+		e.Type,
+		e.CompetitorID,
+		e.ExtraParams,
+	)
+}
+
+func withoutSeq(e Event) Event {
+	e.Seq = 0
+	return e
+}
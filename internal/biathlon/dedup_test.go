@@ -0,0 +1,97 @@
+package biathlon
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDedupSource(t *testing.T) {
+	input := strings.Join([]string{
+		"@1 [09:05:59.867] 1 1",
+		"@2 [09:06:00.000] 1 2",
+		// Retransmitted events are dropped.
+		"@2 [09:06:00.000] 1 2",
+		"@5 [09:06:03.000] 1 5",
+		"@3 [09:06:01.000] 1 3",
+		"@3 [09:06:01.000] 1 30",
+		// Events without numbers are dropped only if they are the same.
+		"[09:06:04.000] 1 6",
+		"[09:06:04.000] 1 6",
+		"[09:06:04.000] 1 7",
+	}, "\n")
+
+	events, errs := collect(NewDedupSource(NewReaderSource("station", strings.NewReader(input))))
+
+	var ids []int
+	for _, e := range events {
+		ids = append(ids, e.CompetitorID)
+	}
+	if want := []int{1, 2, 5, 3, 6, 7}; !slices.Equal(ids, want) {
+		t.Errorf("passed competitors %v, want %v", ids, want)
+	}
+
+	want := []struct {
+		err error
+		msg string
+	}{
+		{ErrSequenceGap, "events are missing: station @3..@4"},
+		{ErrSequenceConflict, "station @3 is event 1 of competitor(3), not event 1 of competitor(30)"},
+		{ErrSequenceGap, "events are missing: station never sent @4"},
+	}
+	if len(errs) != len(want) {
+		t.Fatalf("errs = %v, want %d errors", errs, len(want))
+	}
+	for i, err := range errs {
+		if !errors.Is(err, want[i].err) || !strings.HasSuffix(err.Error(), want[i].msg) {
+			t.Errorf("error %d = %v, want %q", i, err, want[i].msg)
+		}
+	}
+}
+
+func TestDedupSourceReportsMissingWhenInputStops(t *testing.T) {
+	input := newPushSource()
+	dedup := NewDedupSource(input)
+
+	done := make(chan []string)
+	go func() {
+		done <- processLines(t, Config{}, dedup)
+	}()
+
+	input.push(t,
+		Event{TimeStamp: at(9, 0, 1, 0), Type: Register, CompetitorID: 1, Source: "push", Seq: 1},
+		Event{TimeStamp: at(9, 0, 2, 0), Type: Register, CompetitorID: 3, Source: "push", Seq: 3},
+	)
+	input.Stop()
+
+	select {
+	case lines := <-done:
+		expectLines(t, lines, []string{
+			"[09:00:01.000] The competitor(1) registered",
+			"error: events are missing: push @2",
+			"[09:00:02.000] The competitor(3) registered",
+			"error: events are missing: push never sent @2",
+		})
+	case <-time.After(time.Second):
+		t.Fatal("processor hasn't finished in time")
+	}
+}
+
+func TestCSVSourceSeq(t *testing.T) {
+	path := writeCSV(t, "seq,time,event,competitor\n7,09:05:59.867,1,1\n0,09:05:59.900,1,2\n")
+
+	source, err := NewCSVSource(path, DefaultCSVMapping)
+	if err != nil {
+		t.Fatal(err)
+	}
+	events, errs := collect(source)
+
+	if len(events) != 1 || events[0].Seq != 7 || events[0].Source != path {
+		t.Errorf("events = %+v, want one with seq 7 from %s", events, path)
+	}
+	if len(errs) != 1 || !strings.Contains(errs[0].Error(), "expected sequence number") {
+		t.Errorf("errs = %v, want invalid sequence number", errs)
+	}
+}
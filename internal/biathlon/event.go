@@ -2,7 +2,6 @@ package biathlon
 
 import (
 	"errors"
-	"fmt"
 	"strconv"
 	"strings"
 	"time"
@@ -22,15 +21,23 @@ const (
 	LeavePenaltyLap
 	EndMainLap
 	BeUnableToContinue
+	TagOver
+	LoadSpare
 )
 
 const (
 	Disqualify eventType = 32
 	Finish     eventType = 33
+	StartLeg   eventType = 34
+	// SkipPenaltyLoops adds time penalty for penalty loops competitor hasn't skied.
+	SkipPenaltyLoops eventType = 35
 )
 
-var ErrWrongEventFormat = errors.New(
-	"wrong event format: [HH:MM:SS.sss] EventID CompetitorID ExtraParams... required",
+var (
+	ErrWrongEventFormat = errors.New(
+		"wrong event format: [HH:MM:SS.sss] EventID CompetitorID ExtraParams... required",
+	)
+	ErrEmptyLine = errors.New("empty or comment line")
 )
 
 type Event struct {
@@ -38,85 +45,193 @@ type Event struct {
 	Type         eventType
 	CompetitorID int
 	ExtraParams  []any // Should it be a slice? There's always only one extraParams
+
+	// Source is a name of the source event came from.
+	Source string
+	// Seq is a sequence number of event within its source, 0 if it isn't set.
+	Seq int
+}
+
+// paramKind describes extra param an event type requires.
+type paramKind int
+
+const (
+	noParam paramKind = iota
+	timeParam
+	intParam
+	// textParam takes the rest of the line, so it may contain spaces.
+	textParam
+	// reasonParam is a reason code followed by optional
+	// details up to the end of the line.
+	reasonParam
+)
+
+type eventParam struct {
+	kind paramKind
+	name string
+	// field is a name of the param in JSON Lines format.
+	field string
+	// optional param may be omitted.
+	optional bool
+}
+
+// takesRest reports whether param lasts up to the end of the line.
+func (p eventParam) takesRest() bool {
+	return p.kind == textParam || p.kind == reasonParam
+}
+
+var eventParams = map[eventType]eventParam{
+	BeSheduled:         {kind: timeParam, name: "start time", field: "startTime"},
+	ComeToFiringRange:  {kind: intParam, name: "firing range", field: "firingRange"},
+	HitTarget:          {kind: intParam, name: "target", field: "target"},
+	BeUnableToContinue: {kind: textParam, name: "comment", field: "comment"},
+	Disqualify:         {kind: reasonParam, name: "reason code", field: "reason", optional: true},
+	StartLeg:           {kind: intParam, name: "previous competitor", field: "previous"},
+	SkipPenaltyLoops:   {kind: intParam, name: "skipped loops", field: "loops"},
 }
 
+// ParseEvent parses a line of "[HH:MM:SS.sss] EventID CompetitorID ExtraParams"
+// format. Fields may be separated by any amount of spaces or tabs, comment
+// of event 11 and optional reason of event 32 are the rest of the line.
+// Event 32 without reason is a jury decision. Line may start with "@N"
+// sequence number given by timing station. Empty lines and lines starting
+// with '#' are reported with ErrEmptyLine.
 func ParseEvent(eventLine string) (Event, error) {
-	rawEvent := strings.Split(eventLine, " ")
-
-	if len(rawEvent) < 3 {
-		return Event{}, fmt.Errorf(
-			"%d out of at least 3 params are passed: %w",
-			len(rawEvent),
-			ErrWrongEventFormat,
-		)
+	lex := newLexer(eventLine)
+	if lex.skipLine() {
+		return Event{}, ErrEmptyLine
 	}
 
-	e := Event{}
-	timeStamp, err := parseEventTime(rawEvent[0])
+	seq := 0
+	timeStamp := lex.next()
+	if strings.HasPrefix(timeStamp.text, "@") {
+		n, err := strconv.Atoi(timeStamp.text[1:])
+		if err != nil || n < 1 {
+			return Event{}, unexpected(timeStamp, "sequence number @N", err)
+		}
+		seq = n
+		timeStamp = lex.next()
+	}
+	eventID := lex.next()
+	competitorID := lex.next()
+
+	var extra token
+	if id, err := strconv.Atoi(eventID.text); err == nil && eventParams[eventType(id)].takesRest() {
+		extra = lex.rest()
+	} else {
+		extra = lex.next()
+	}
+
+	event, err := eventFromTokens(timeStamp, eventID, competitorID, extra)
 	if err != nil {
-		return Event{}, fmt.Errorf("%w: %w", err, ErrWrongEventFormat)
+		return Event{}, err
+	}
+
+	if trailing := lex.next(); trailing.text != "" {
+		return Event{}, unexpected(trailing, "end of line", nil)
+	}
+	event.Seq = seq
+
+	return event, nil
+}
+
+// eventFromTokens builds Event from its raw fields. Extra token
+// with empty text means that there is no extra param.
+func eventFromTokens(timeStamp, eventID, competitorID, extra token) (Event, error) {
+	e := Event{}
+
+	t, err := parseEventTime(timeStamp.text)
+	if err != nil || !hasBalancedBrackets(timeStamp.text) {
+		return Event{}, unexpected(timeStamp, "timestamp [HH:MM:SS.sss]", err)
 	}
-	e.TimeStamp = timeStamp
+	e.TimeStamp = t
 
-	eventID, err := strconv.Atoi(rawEvent[1])
+	id, err := strconv.Atoi(eventID.text)
 	if err != nil {
-		return Event{}, fmt.Errorf("%w: %w", err, ErrWrongEventFormat)
+		return Event{}, unexpected(eventID, "event id", err)
 	}
-	e.Type = eventType(eventID)
+	e.Type = eventType(id)
 
-	competitorID, err := strconv.Atoi(rawEvent[2])
+	cID, err := strconv.Atoi(competitorID.text)
 	if err != nil {
-		return Event{}, fmt.Errorf("%w: %w", err, ErrWrongEventFormat)
+		return Event{}, unexpected(competitorID, "competitor id", err)
 	}
-	e.CompetitorID = competitorID
+	e.CompetitorID = cID
 
-	switch e.Type {
-	case BeSheduled:
-		if len(rawEvent) != 4 {
-			return Event{}, fmt.Errorf("%d event requires 4th param as start time", BeSheduled)
+	param, ok := eventParams[e.Type]
+	if !ok {
+		if extra.text != "" {
+			return Event{}, unexpected(extra, "end of line", nil)
 		}
-		timeStamp, err := parseEventTime(rawEvent[3])
-		if err != nil {
-			return Event{}, err
-		}
-		e.ExtraParams = append(e.ExtraParams, timeStamp)
-	case ComeToFiringRange:
-		if len(rawEvent) != 4 {
-			return Event{}, fmt.Errorf(
-				"%d event requires 4th param as range number",
-				ComeToFiringRange,
-			)
+		return e, nil
+	}
+
+	if extra.text == "" {
+		if param.optional {
+			if param.kind == reasonParam {
+				e.ExtraParams = append(e.ExtraParams, Reason{Code: JuryDecision})
+			}
+			return e, nil
 		}
-		firingRange, err := strconv.Atoi(rawEvent[3])
+		return Event{}, unexpected(extra, param.name, nil)
+	}
+
+	switch param.kind {
+	case timeParam:
+		t, err := parseEventTime(extra.text)
 		if err != nil {
-			return Event{}, err
-		}
-		e.ExtraParams = append(e.ExtraParams, firingRange)
-	case HitTarget:
-		if len(rawEvent) != 4 {
-			return Event{}, fmt.Errorf("%d event requires 4th param as target", HitTarget)
+			return Event{}, unexpected(extra, param.name, err)
 		}
-		target, err := strconv.Atoi(rawEvent[3])
+		e.ExtraParams = append(e.ExtraParams, t)
+	case intParam:
+		v, err := strconv.Atoi(extra.text)
 		if err != nil {
-			return Event{}, err
+			return Event{}, unexpected(extra, param.name, err)
 		}
-		e.ExtraParams = append(e.ExtraParams, target)
-	case BeUnableToContinue:
-		if len(rawEvent) != 4 {
-			return Event{}, fmt.Errorf("%d event requires 4th param as comment", BeUnableToContinue)
+		e.ExtraParams = append(e.ExtraParams, v)
+	case textParam:
+		e.ExtraParams = append(e.ExtraParams, extra.text)
+	case reasonParam:
+		r, ok := parseReason(extra.text)
+		if !ok {
+			return Event{}, unexpected(extra, param.name, nil)
 		}
-		comment := rawEvent[3]
-		e.ExtraParams = append(e.ExtraParams, comment)
+		e.ExtraParams = append(e.ExtraParams, r)
 	}
 
 	return e, nil
 }
 
+// hasBalancedBrackets reports whether timestamp is either wrapped in square
+// brackets or has none of them. Brackets are optional e.g. in CSV.
+func hasBalancedBrackets(s string) bool {
+	return strings.HasPrefix(s, "[") == strings.HasSuffix(s, "]")
+}
+
+// dateTimeLayout is used for timestamps with date,
+// e.g. for multi-day competitions.
+// Fraction of a second is optional when parsing, like with time.TimeOnly.
+const dateTimeLayout = "2006-01-02T15:04:05"
+
+// parseEventTime accepts time with or without date. Timestamps
+// without date are placed on year 0 until DateResolver resolves them.
 func parseEventTime(rawTime string) (time.Time, error) {
 	trimmedtime := strings.Trim(rawTime, `[]`)
+	if strings.Contains(trimmedtime, "T") {
+		return time.Parse(dateTimeLayout, trimmedtime)
+	}
+
 	t, err := time.Parse(time.TimeOnly, trimmedtime)
 	if err != nil {
 		return time.Time{}, err
 	}
 	return t, nil
 }
+
+// formatEventTime prints timestamp with date only if it has one.
+func formatEventTime(t time.Time) string {
+	if hasDate(t) {
+		return t.Format(dateTimeLayout + ".000")
+	}
+	return t.Format("15:04:05.000")
+}
@@ -0,0 +1,200 @@
+package biathlon
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// LineParser converts one line of input into Event.
+type LineParser func(line string) (Event, error)
+
+// jsonEvent holds fields of Event in JSON Lines format shared by all event types.
+// Extra param is stored in a typed field named by eventParams instead of a list.
+type jsonEvent struct {
+	Seq          int    `json:"seq,omitempty"`
+	Time         string `json:"time"`
+	Event        int    `json:"event"`
+	CompetitorID int    `json:"competitor"`
+}
+
+// jsonDetailField holds details of the reason next to its code.
+const jsonDetailField = "detail"
+
+// ParseEventJSON converts one line of JSON Lines input into Event.
+// Empty lines are reported with ErrEmptyLine.
+func ParseEventJSON(line string) (Event, error) {
+	if strings.TrimSpace(line) == "" {
+		return Event{}, ErrEmptyLine
+	}
+
+	e := Event{}
+	if err := json.Unmarshal([]byte(line), &e); err != nil {
+		return Event{}, err
+	}
+	return e, nil
+}
+
+func (e Event) MarshalJSON() ([]byte, error) {
+	b, err := json.Marshal(jsonEvent{
+		Seq:          e.Seq,
+		Time:         formatEventTime(e.TimeStamp),
+		Event:        int(e.Type),
+		CompetitorID: e.CompetitorID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	param, ok := eventParams[e.Type]
+	if !ok || len(e.ExtraParams) == 0 {
+		return b, nil
+	}
+
+	// Fields of extra param are appended to the object
+	// to keep them after the shared ones.
+	var buf bytes.Buffer
+	buf.Write(b[:len(b)-1])
+	for _, field := range param.jsonFields(e.ExtraParams[0]) {
+		value, err := json.Marshal(field.value)
+		if err != nil {
+			return nil, err
+		}
+		fmt.Fprintf(&buf, ",%q:%s", field.key, value)
+	}
+	buf.WriteByte('}')
+
+	return buf.Bytes(), nil
+}
+
+// jsonField is a key of JSON object with its value.
+type jsonField struct {
+	key   string
+	value any
+}
+
+// jsonFields represents value of extra param in JSON Lines format.
+func (p eventParam) jsonFields(value any) []jsonField {
+	switch p.kind {
+	case timeParam:
+		return []jsonField{{p.field, formatEventTime(value.(time.Time))}}
+	case reasonParam:
+		reason := value.(Reason)
+		fields := []jsonField{{p.field, string(reason.Code)}}
+		if reason.Detail != "" {
+			fields = append(fields, jsonField{jsonDetailField, reason.Detail})
+		}
+		return fields
+	default:
+		return []jsonField{{p.field, value}}
+	}
+}
+
+func (e *Event) UnmarshalJSON(b []byte) error {
+	je := jsonEvent{}
+	if err := json.Unmarshal(b, &je); err != nil {
+		return err
+	}
+
+	timeStamp, err := parseEventTime(je.Time)
+	if err != nil {
+		return fmt.Errorf("%w: %w", err, ErrWrongEventFormat)
+	}
+
+	ev := Event{
+		TimeStamp:    timeStamp,
+		Type:         eventType(je.Event),
+		CompetitorID: je.CompetitorID,
+		Seq:          je.Seq,
+	}
+
+	param, ok := eventParams[ev.Type]
+	if !ok {
+		*e = ev
+		return nil
+	}
+
+	fields := map[string]json.RawMessage{}
+	if err := json.Unmarshal(b, &fields); err != nil {
+		return err
+	}
+
+	raw, ok := fields[param.field]
+	if !ok {
+		if !param.optional {
+			return fmt.Errorf("%d event requires %s field", ev.Type, param.field)
+		}
+		if param.kind == reasonParam {
+			ev.ExtraParams = append(ev.ExtraParams, Reason{Code: JuryDecision})
+		}
+		*e = ev
+		return nil
+	}
+
+	value, err := param.fromJSON(raw, fields)
+	if err != nil {
+		return fmt.Errorf("%d event has invalid %s field: %w", ev.Type, param.field, err)
+	}
+	ev.ExtraParams = append(ev.ExtraParams, value)
+
+	*e = ev
+	return nil
+}
+
+// fromJSON is the inverse of jsonFields.
+func (p eventParam) fromJSON(raw json.RawMessage, fields map[string]json.RawMessage) (any, error) {
+	switch p.kind {
+	case timeParam:
+		var s string
+		if err := json.Unmarshal(raw, &s); err != nil {
+			return nil, err
+		}
+		return parseEventTime(s)
+	case intParam:
+		var v int
+		err := json.Unmarshal(raw, &v)
+		return v, err
+	case reasonParam:
+		var code string
+		if err := json.Unmarshal(raw, &code); err != nil {
+			return nil, err
+		}
+		reason := Reason{Code: ReasonCode(code)}
+		if !reason.Code.valid() {
+			return nil, fmt.Errorf("unknown reason code %q", code)
+		}
+		if detail, ok := fields[jsonDetailField]; ok {
+			if err := json.Unmarshal(detail, &reason.Detail); err != nil {
+				return nil, err
+			}
+		}
+		return reason, nil
+	default:
+		var s string
+		err := json.Unmarshal(raw, &s)
+		return s, err
+	}
+}
+
+// JSONLogger writes processed and generated events in JSON Lines format,
+// so that they could be read back with ParseEventJSON. Errors are skipped.
+type JSONLogger struct {
+	enc *json.Encoder
+}
+
+func NewJSONLogger(out io.Writer) *JSONLogger {
+	return &JSONLogger{
+		enc: json.NewEncoder(out),
+	}
+}
+
+func (l *JSONLogger) Error(time.Time, error) {}
+
+func (l *JSONLogger) Event(e Event) {
+	if err := l.enc.Encode(e); err != nil {
+		fmt.Printf("Logger error: %s\n", err.Error())
+	}
+}
@@ -0,0 +1,107 @@
+package biathlon
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestEventJSONRoundTrip(t *testing.T) {
+	events := []Event{
+		{TimeStamp: at(9, 5, 59, 867), Type: Register, CompetitorID: 1, Seq: 3},
+		{TimeStamp: at(9, 15, 0, 841), Type: BeSheduled, CompetitorID: 1, ExtraParams: []any{at(9, 30, 0, 0)}},
+		{TimeStamp: at(9, 49, 31, 659), Type: ComeToFiringRange, CompetitorID: 1, ExtraParams: []any{1}},
+		{TimeStamp: at(9, 49, 33, 123), Type: HitTarget, CompetitorID: 1, ExtraParams: []any{5}},
+		{TimeStamp: at(9, 59, 3, 872), Type: BeUnableToContinue, CompetitorID: 1, ExtraParams: []any{"Lost in the forest"}},
+		{TimeStamp: at(10, 0, 0, 0), Type: Disqualify, CompetitorID: 2, ExtraParams: []any{Reason{Code: RangesMissed, Detail: "range(2)"}}},
+		{TimeStamp: at(10, 0, 0, 0), Type: Disqualify, CompetitorID: 3, ExtraParams: []any{Reason{Code: JuryDecision}}},
+		{TimeStamp: at(10, 0, 0, 0), Type: StartLeg, CompetitorID: 4, ExtraParams: []any{1}},
+		{TimeStamp: at(10, 0, 0, 0), Type: SkipPenaltyLoops, CompetitorID: 1, ExtraParams: []any{2}},
+	}
+
+	for _, want := range events {
+		b, err := want.MarshalJSON()
+		if err != nil {
+			t.Fatalf("MarshalJSON(%+v): %v", want, err)
+		}
+		got, err := ParseEventJSON(string(b))
+		if err != nil {
+			t.Fatalf("ParseEventJSON(%s): %v", b, err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("ParseEventJSON(%s) = %+v, want %+v", b, got, want)
+		}
+	}
+}
+
+func TestEventMarshalJSON(t *testing.T) {
+	e := Event{TimeStamp: at(9, 15, 0, 841), Type: BeSheduled, CompetitorID: 1, ExtraParams: []any{at(9, 30, 0, 0)}}
+
+	b, err := e.MarshalJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"time":"09:15:00.841","event":2,"competitor":1,"startTime":"09:30:00.000"}`
+	if string(b) != want {
+		t.Errorf("MarshalJSON() = %s, want %s", b, want)
+	}
+}
+
+func TestParseEventJSONErrors(t *testing.T) {
+	tests := []struct {
+		line string
+		want string
+	}{
+		{`{"time":"9 o'clock","event":1,"competitor":1}`, ErrWrongEventFormat.Error()},
+		{`{"time":"09:49:31.659","event":5,"competitor":1}`, "5 event requires firingRange field"},
+		{`{"time":"09:49:31.659","event":5,"competitor":1,"firingRange":"1"}`, "5 event has invalid firingRange field"},
+		{`{"time":"09:15:00.841","event":2,"competitor":1,"startTime":"later"}`, "2 event has invalid startTime field"},
+		{`{"time":"10:00:00.000","event":32,"competitor":1,"reason":"Doping"}`, `32 event has invalid reason field: unknown reason code "Doping"`},
+		{`[09:05:59.867] 1 1`, "invalid character"},
+	}
+
+	for _, tt := range tests {
+		_, err := ParseEventJSON(tt.line)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("ParseEventJSON(%s) error = %v, want %q", tt.line, err, tt.want)
+		}
+	}
+}
+
+func TestJSONLogger(t *testing.T) {
+	var out bytes.Buffer
+	var text bytes.Buffer
+	log := MultiLogger{NewJSONLogger(&out), NewDefaultLogger(&text)}
+
+	log.Event(Event{TimeStamp: at(9, 5, 59, 867), Type: Register, CompetitorID: 1})
+	log.Error(at(9, 6, 0, 0), errors.New("skipped in JSON"))
+	log.Event(Event{TimeStamp: at(9, 49, 33, 123), Type: HitTarget, CompetitorID: 1, ExtraParams: []any{5}})
+
+	want := `{"time":"09:05:59.867","event":1,"competitor":1}
+{"time":"09:49:33.123","event":6,"competitor":1,"target":5}
+`
+	if out.String() != want {
+		t.Errorf("JSON log:\n%s\nwant:\n%s", out.String(), want)
+	}
+	if lines := strings.Count(text.String(), "\n"); lines != 3 {
+		t.Errorf("text log has %d lines, want 3:\n%s", lines, text.String())
+	}
+}
+
+func TestReaderSourceJSON(t *testing.T) {
+	input := `{"time":"09:05:59.867","event":1,"competitor":1}
+{"time":"09:49:31.659","event":5,"competitor":1,"firingRange":1}`
+
+	source := NewReaderSource("input.jsonl", strings.NewReader(input))
+	source.SetParser(ParseEventJSON)
+	events, errs := collect(source)
+
+	if len(errs) != 0 {
+		t.Errorf("unexpected errors %v", errs)
+	}
+	if len(events) != 2 || events[1].Type != ComeToFiringRange || events[1].ExtraParams[0] != 1 {
+		t.Errorf("events = %+v", events)
+	}
+}
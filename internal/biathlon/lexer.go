@@ -0,0 +1,104 @@
+package biathlon
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ParseError describes the place where event doesn't match its grammar.
+type ParseError struct {
+	// Line is a number of line in the source, 0 if it's unknown.
+	Line int
+	// Column is a number of the first character of the wrong token.
+	Column   int
+	Expected string
+	Found    string
+	Err      error
+}
+
+func (e *ParseError) Error() string {
+	var sb strings.Builder
+	if e.Line > 0 {
+		sb.WriteString(fmt.Sprintf("line %d, ", e.Line))
+	}
+
+	found := "end of line"
+	if e.Found != "" {
+		found = fmt.Sprintf("%q", e.Found)
+	}
+	sb.WriteString(fmt.Sprintf("column %d: expected %s, found %s", e.Column, e.Expected, found))
+
+	if e.Err != nil {
+		sb.WriteString(": ")
+		sb.WriteString(e.Err.Error())
+	}
+	return sb.String()
+}
+
+func (e *ParseError) Unwrap() []error {
+	if e.Err == nil {
+		return []error{ErrWrongEventFormat}
+	}
+	return []error{ErrWrongEventFormat, e.Err}
+}
+
+// token is a piece of event line with 1-based column it starts at.
+type token struct {
+	text   string
+	column int
+}
+
+func unexpected(t token, expected string, err error) *ParseError {
+	return &ParseError{
+		Column:   t.column,
+		Expected: expected,
+		Found:    t.text,
+		Err:      err,
+	}
+}
+
+// lexer splits event line into whitespace separated tokens.
+type lexer struct {
+	input string
+	pos   int
+}
+
+func newLexer(line string) *lexer {
+	return &lexer{
+		input: strings.TrimRight(line, "\r\n"),
+	}
+}
+
+// skipLine reports whether line is empty or is a comment.
+func (l *lexer) skipLine() bool {
+	trimmed := strings.TrimSpace(l.input)
+	return trimmed == "" || strings.HasPrefix(trimmed, "#")
+}
+
+func (l *lexer) skipSpace() {
+	for l.pos < len(l.input) && isSpace(l.input[l.pos]) {
+		l.pos++
+	}
+}
+
+// next returns the next token. Token with empty text means end of line.
+func (l *lexer) next() token {
+	l.skipSpace()
+	start := l.pos
+	for l.pos < len(l.input) && !isSpace(l.input[l.pos]) {
+		l.pos++
+	}
+	return token{text: l.input[start:l.pos], column: start + 1}
+}
+
+// rest returns everything till the end of line without surrounding spaces.
+func (l *lexer) rest() token {
+	l.skipSpace()
+	start := l.pos
+	l.pos = len(l.input)
+	return token{text: strings.TrimRight(l.input[start:], " \t"), column: start + 1}
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t'
+}
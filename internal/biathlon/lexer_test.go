@@ -0,0 +1,118 @@
+package biathlon
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestParseEventErrors(t *testing.T) {
+	tests := []struct {
+		line     string
+		column   int
+		expected string
+		found    string
+	}{
+		{"9:05 1 1", 1, "timestamp [HH:MM:SS.sss]", "9:05"},
+		{"[09:05:59.867 1 1", 1, "timestamp [HH:MM:SS.sss]", "[09:05:59.867"},
+		{"@0 [09:05:59.867] 1 1", 1, "sequence number @N", "@0"},
+		{"[09:05:59.867] x 1", 16, "event id", "x"},
+		{"[09:05:59.867]  1  y", 20, "competitor id", "y"},
+		{"[09:05:59.867] 1", 17, "competitor id", ""},
+		{"[09:05:59.867] 5 1", 19, "firing range", ""},
+		{"[09:05:59.867]\t6\t1\tx", 20, "target", "x"},
+		{"[09:05:59.867] 1 1 extra", 20, "end of line", "extra"},
+		{"[09:05:59.867] 6 1 1 2", 22, "end of line", "2"},
+		{"@2 [09:05:59.867] 2 1 9.30", 23, "start time", "9.30"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			_, err := ParseEvent(tt.line)
+			if !errors.Is(err, ErrWrongEventFormat) {
+				t.Fatalf("got %v, want ErrWrongEventFormat", err)
+			}
+
+			var parseErr *ParseError
+			if !errors.As(err, &parseErr) {
+				t.Fatalf("got %T, want *ParseError", err)
+			}
+			if parseErr.Column != tt.column || parseErr.Expected != tt.expected || parseErr.Found != tt.found {
+				t.Errorf(
+					"got column %d, expected %q, found %q; want column %d, expected %q, found %q",
+					parseErr.Column, parseErr.Expected, parseErr.Found,
+					tt.column, tt.expected, tt.found,
+				)
+			}
+		})
+	}
+}
+
+func TestParseErrorLine(t *testing.T) {
+	_, err := ParseEvent("[09:05:59.867] x 1")
+	err = withLine(err, 12)
+
+	want := `line 12, column 16: expected event id, found "x": ` +
+		`strconv.Atoi: parsing "x": invalid syntax`
+	if err.Error() != want {
+		t.Errorf("got %q, want %q", err.Error(), want)
+	}
+}
+
+func TestParseEventComment(t *testing.T) {
+	e, err := ParseEvent("[09:59:03.872]   11 1  Lost in\tthe forest ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.Type != BeUnableToContinue || e.ExtraParams[0] != "Lost in\tthe forest" {
+		t.Errorf("ParseEvent() = %+v", e)
+	}
+}
+
+func TestParseEventSkipsLines(t *testing.T) {
+	for _, line := range []string{"", "   ", "\r\n", "# comment", "  # indented comment"} {
+		if _, err := ParseEvent(line); !errors.Is(err, ErrEmptyLine) {
+			t.Errorf("ParseEvent(%q): got %v, want ErrEmptyLine", line, err)
+		}
+	}
+}
+
+func TestReaderSourceReportsLines(t *testing.T) {
+	input := strings.Join([]string{
+		"# registration",
+		"[09:05:59.867] 1 1",
+		"",
+		"[09:05:59.867] x 2",
+	}, "\n")
+
+	events, errs := collect(NewReaderSource("input", strings.NewReader(input)))
+	if len(events) != 1 {
+		t.Errorf("events = %+v, want one event", events)
+	}
+	if len(errs) != 1 || !strings.HasPrefix(errs[0].Error(), "input: line 4, column 16: ") {
+		t.Errorf("errs = %v, want error at line 4", errs)
+	}
+}
+
+func TestLexer(t *testing.T) {
+	lex := newLexer("\t[09:05:59.867]  11 1  Lost in\tthe forest \r\n")
+
+	want := []token{
+		{text: "[09:05:59.867]", column: 2},
+		{text: "11", column: 18},
+		{text: "1", column: 21},
+	}
+	for _, w := range want {
+		if got := lex.next(); got != w {
+			t.Errorf("next() = %+v, want %+v", got, w)
+		}
+	}
+
+	rest := token{text: "Lost in\tthe forest", column: 24}
+	if got := lex.rest(); got != rest {
+		t.Errorf("rest() = %+v, want %+v", got, rest)
+	}
+	if got := lex.next(); got.text != "" {
+		t.Errorf("next() after rest() = %+v, want end of line", got)
+	}
+}
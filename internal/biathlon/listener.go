@@ -2,13 +2,34 @@ package biathlon
 
 import (
 	"bufio"
+	"errors"
+	"fmt"
+	"io"
 	"log"
 	"os"
+	"strings"
+	"time"
 )
 
+// EndOfRaceMarker is a line that explicitly ends the race. Everything
+// after it is ignored and the events channel gets closed.
+const EndOfRaceMarker = "END"
+
+const defaultPollInterval = 250 * time.Millisecond
+
+// EventListener reads events from file.
 type EventListener struct {
-	events chan Event
-	file   *os.File
+	stream
+	file     *os.File
+	filepath string
+
+	// follow keeps listening for new lines after EOF like `tail -f` does.
+	follow       bool
+	pollInterval time.Duration
+
+	parser LineParser
+	// line is a number of the last read line.
+	line int
 
 	log *log.Logger
 }
@@ -20,32 +41,154 @@ func NewEventListener(filepath string) (*EventListener, error) {
 	}
 
 	return &EventListener{
-		events: make(chan Event),
-		file:   f, log: log.New(os.Stdout, "Listener: ", log.Ltime),
+		stream:       newStream(),
+		file:         f,
+		filepath:     filepath,
+		pollInterval: defaultPollInterval,
+		parser:       ParseEvent,
+		log:          log.New(os.Stdout, "Listener: ", log.Ltime),
 	}, nil
 }
 
-func (l *EventListener) Events() <-chan Event {
-	return l.events
+func (l *EventListener) Name() string {
+	return l.filepath
 }
 
 func (l *EventListener) SetLogger(log *log.Logger) {
 	l.log = log
 }
 
+// SetParser sets format of the lines in file. ParseEvent is used by default.
+func (l *EventListener) SetParser(parser LineParser) {
+	l.parser = parser
+}
+
+// SetFollow enables follow mode. In follow mode listener doesn't stop at EOF
+// and waits for new lines until EndOfRaceMarker is read or Stop is called.
+func (l *EventListener) SetFollow(follow bool) {
+	l.follow = follow
+}
+
+func (l *EventListener) SetPollInterval(interval time.Duration) {
+	l.pollInterval = interval
+}
+
 func (l *EventListener) Start() {
-	scanner := bufio.NewScanner(l.file)
-	for scanner.Scan() {
-		event, err := ParseEvent(scanner.Text())
+	defer l.close()
+
+	reader := bufio.NewReader(l.file)
+	var offset int64
+	var partial string
+
+	for {
+		chunk, err := reader.ReadString('\n')
+		offset += int64(len(chunk))
+		partial += chunk
+
+		if err == nil {
+			line := strings.TrimRight(partial, "\r\n")
+			partial = ""
+			if !l.emit(line) {
+				break
+			}
+			continue
+		}
+
+		if err != io.EOF {
+			l.fail(fmt.Errorf("%s: %w", l.filepath, err))
+			break
+		}
+
+		if !l.follow {
+			if partial != "" {
+				l.emit(strings.TrimRight(partial, "\r"))
+			}
+			break
+		}
+
+		// Line without trailing newline may still be written,
+		// so it's kept in partial until the rest of it arrives.
+		select {
+		case <-l.done:
+			l.closeFile()
+			return
+		case <-time.After(l.pollInterval):
+		}
+
+		reopened, err := l.checkFile(offset)
+		if err != nil {
+			l.fail(fmt.Errorf("%s: %w", l.filepath, err))
+			continue
+		}
+		if reopened {
+			reader.Reset(l.file)
+			offset = 0
+			partial = ""
+			l.line = 0
+		}
+	}
+
+	l.closeFile()
+}
+
+// emit parses line and sends it to the events channel.
+// It returns false when listening should be stopped.
+func (l *EventListener) emit(line string) bool {
+	if strings.TrimSpace(line) == EndOfRaceMarker {
+		return false
+	}
+
+	l.line++
+	event, err := l.parser(line)
+	if errors.Is(err, ErrEmptyLine) {
+		return true
+	}
+	if err != nil {
+		return l.fail(fmt.Errorf("%s: %w", l.filepath, withLine(err, l.line)))
+	}
+
+	event.Source = l.filepath
+	return l.send(event)
+}
+
+// checkFile detects truncation and rotation of the followed file.
+// It returns true if reading must start from the beginning of l.file.
+func (l *EventListener) checkFile(offset int64) (bool, error) {
+	current, err := l.file.Stat()
+	if err != nil {
+		return false, err
+	}
+
+	onDisk, err := os.Stat(l.filepath)
+	if err != nil {
+		// File may be missing for a moment while being rotated.
+		return false, nil
+	}
+
+	if !os.SameFile(current, onDisk) {
+		f, err := os.Open(l.filepath)
 		if err != nil {
-			l.log.Println(err)
+			return false, err
 		}
+		l.closeFile()
+		l.file = f
+		l.log.Printf("%s was rotated, reading new file\n", l.filepath)
+		return true, nil
+	}
 
-		l.events <- event
+	if current.Size() < offset {
+		if _, err := l.file.Seek(0, io.SeekStart); err != nil {
+			return false, err
+		}
+		l.log.Printf("%s was truncated, reading from the beginning\n", l.filepath)
+		return true, nil
 	}
-	close(l.events)
 
+	return false, nil
+}
+
+func (l *EventListener) closeFile() {
 	if err := l.file.Close(); err != nil {
-		l.log.Fatalln(err)
+		l.fail(fmt.Errorf("%s: %w", l.filepath, err))
 	}
 }
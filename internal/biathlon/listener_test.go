@@ -0,0 +1,148 @@
+package biathlon
+
+import (
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func newTestListener(t *testing.T, content string) (*EventListener, string) {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "events")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	listener, err := NewEventListener(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	listener.SetLogger(log.New(io.Discard, "", 0))
+	listener.SetPollInterval(time.Millisecond)
+	return listener, path
+}
+
+func appendLines(t *testing.T, path, lines string) {
+	t.Helper()
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	if _, err := f.WriteString(lines); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func nextEvent(t *testing.T, events <-chan Event) Event {
+	t.Helper()
+
+	select {
+	case e, ok := <-events:
+		if !ok {
+			t.Fatal("events channel is closed")
+		}
+		return e
+	case <-time.After(time.Second):
+		t.Fatal("no event in time")
+	}
+	return Event{}
+}
+
+func expectClosed(t *testing.T, events <-chan Event) {
+	t.Helper()
+
+	select {
+	case e, ok := <-events:
+		if ok {
+			t.Fatalf("unexpected event %+v", e)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("events channel isn't closed in time")
+	}
+}
+
+func TestEventListenerReadsFile(t *testing.T) {
+	listener, _ := newTestListener(t, "[09:05:59.867] 1 1\n[09:15:00.841] 2 1 [09:30:00.000]\n[09:29:45.734] 3 1")
+	go listener.Start()
+
+	events := listener.Events()
+	if e := nextEvent(t, events); e.Type != Register || e.CompetitorID != 1 {
+		t.Errorf("first event = %+v", e)
+	}
+	if e := nextEvent(t, events); e.Type != BeSheduled {
+		t.Errorf("second event = %+v", e)
+	}
+	// Last line has no trailing newline, but the file is complete.
+	if e := nextEvent(t, events); e.Type != ComeToStartLine {
+		t.Errorf("third event = %+v", e)
+	}
+	expectClosed(t, events)
+}
+
+func TestEventListenerStopsAtEndMarker(t *testing.T) {
+	listener, _ := newTestListener(t, "[09:05:59.867] 1 1\nEND\n[09:05:59.867] 1 2\n")
+	listener.SetFollow(true)
+	go listener.Start()
+
+	events := listener.Events()
+	if e := nextEvent(t, events); e.CompetitorID != 1 {
+		t.Errorf("first event = %+v", e)
+	}
+	expectClosed(t, events)
+}
+
+func TestEventListenerFollow(t *testing.T) {
+	listener, path := newTestListener(t, "[09:05:59.867] 1 1\n")
+	listener.SetFollow(true)
+	go listener.Start()
+
+	events := listener.Events()
+	nextEvent(t, events)
+
+	// Line is written in two parts and must be read as a whole.
+	appendLines(t, path, "[09:05:59.900] 1")
+	time.Sleep(10 * time.Millisecond)
+	appendLines(t, path, " 2\n")
+	if e := nextEvent(t, events); e.Type != Register || e.CompetitorID != 2 {
+		t.Errorf("event after append = %+v", e)
+	}
+
+	if err := os.WriteFile(path, []byte("[09:06:00.000] 1 3\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if e := nextEvent(t, events); e.CompetitorID != 3 {
+		t.Errorf("event after truncation = %+v", e)
+	}
+
+	rotated := path + ".new"
+	if err := os.WriteFile(rotated, []byte("[09:06:01.000] 1 4\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(rotated, path); err != nil {
+		t.Fatal(err)
+	}
+	if e := nextEvent(t, events); e.CompetitorID != 4 {
+		t.Errorf("event after rotation = %+v", e)
+	}
+
+	appendLines(t, path, "END\n")
+	expectClosed(t, events)
+}
+
+func TestEventListenerStop(t *testing.T) {
+	listener, _ := newTestListener(t, "")
+	listener.SetFollow(true)
+	go listener.Start()
+
+	time.Sleep(10 * time.Millisecond)
+	listener.Stop()
+	listener.Stop()
+	expectClosed(t, listener.Events())
+}
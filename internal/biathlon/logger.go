@@ -11,6 +11,21 @@ type Logger interface {
 	Error(time time.Time, err error)
 }
 
+// MultiLogger duplicates everything to all its loggers.
+type MultiLogger []Logger
+
+func (m MultiLogger) Error(time time.Time, err error) {
+	for _, l := range m {
+		l.Error(time, err)
+	}
+}
+
+func (m MultiLogger) Event(e Event) {
+	for _, l := range m {
+		l.Event(e)
+	}
+}
+
 type DefaultLogger struct {
 	out io.Writer
 }
@@ -21,9 +36,13 @@ func NewDefaultLogger(out io.Writer) *DefaultLogger {
 	}
 }
 
+// Error prints err with time of the last processed event,
+// which is zero if err precedes all events.
 func (l *DefaultLogger) Error(time time.Time, err error) {
-	ts := time.Format("15:04:05.000")
-	msg := fmt.Sprintf("[%s] %s\n", ts, err.Error())
+	msg := err.Error() + "\n"
+	if !time.IsZero() {
+		msg = fmt.Sprintf("[%s] %s", formatEventTime(time), msg)
+	}
 
 	if _, err := l.out.Write([]byte(msg)); err != nil {
 		fmt.Printf("Logger error: %s\n", err.Error())
@@ -39,7 +58,7 @@ func (l *DefaultLogger) Event(e Event) {
 }
 
 func (l *DefaultLogger) msgFromEvent(e Event) string {
-	ts := e.TimeStamp.Format("15:04:05.000")
+	ts := formatEventTime(e.TimeStamp)
 	switch e.Type {
 	case Register:
 		return fmt.Sprintf("[%s] The competitor(%d) registered\n", ts, e.CompetitorID)
@@ -47,7 +66,7 @@ func (l *DefaultLogger) msgFromEvent(e Event) string {
 	case BeSheduled:
 		t := e.ExtraParams[0].(time.Time)
 		return fmt.Sprintf("[%s] The start time for the competitor(%d) was set by a draw to %s\n",
-			ts, e.CompetitorID, t.Format("15:04:05.000"))
+			ts, e.CompetitorID, formatEventTime(t))
 
 	case ComeToStartLine:
 		return fmt.Sprintf("[%s] The competitor(%d) is on the start line\n", ts, e.CompetitorID)
@@ -94,12 +113,40 @@ func (l *DefaultLogger) msgFromEvent(e Event) string {
 			comment,
 		)
 
+	case TagOver:
+		return fmt.Sprintf("[%s] The competitor(%d) tagged over\n", ts, e.CompetitorID)
+
+	case LoadSpare:
+		return fmt.Sprintf("[%s] The competitor(%d) loaded a spare round\n", ts, e.CompetitorID)
+
 	case Disqualify:
+		if len(e.ExtraParams) > 0 {
+			reason := e.ExtraParams[0].(Reason)
+			return fmt.Sprintf("[%s] The competitor(%d) is disqualified: %s\n", ts, e.CompetitorID, reason.Describe())
+		}
 		return fmt.Sprintf("[%s] The competitor(%d) is disqualified\n", ts, e.CompetitorID)
 
 	case Finish:
 		return fmt.Sprintf("[%s] The competitor(%d) has finished\n", ts, e.CompetitorID)
 
+	case StartLeg:
+		previous := e.ExtraParams[0].(int)
+		return fmt.Sprintf(
+			"[%s] The competitor(%d) started the leg after competitor(%d)\n",
+			ts,
+			e.CompetitorID,
+			previous,
+		)
+
+	case SkipPenaltyLoops:
+		loops := e.ExtraParams[0].(int)
+		return fmt.Sprintf(
+			"[%s] The competitor(%d) is penalized for %d skipped penalty loops\n",
+			ts,
+			e.CompetitorID,
+			loops,
+		)
+
 	default:
 		return fmt.Sprintf("[%s] Unknown event(%d) for competitor(%d)\n", ts, e.Type, e.CompetitorID)
 	}
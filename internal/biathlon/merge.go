@@ -0,0 +1,149 @@
+package biathlon
+
+import (
+	"container/heap"
+	"strings"
+)
+
+// MergeSource merges several time-ordered sources into one time-ordered
+// stream. Events with equal timestamps are ordered by priority of their
+// sources (lower value goes first), then by order in which sources were added.
+type MergeSource struct {
+	stream
+	inputs []mergeInput
+}
+
+type mergeInput struct {
+	source   EventSource
+	priority int
+}
+
+func NewMergeSource() *MergeSource {
+	return &MergeSource{
+		stream: newStream(),
+	}
+}
+
+// Add registers source with the given priority.
+// It must be called before Start.
+func (m *MergeSource) Add(source EventSource, priority int) {
+	m.inputs = append(m.inputs, mergeInput{source: source, priority: priority})
+}
+
+func (m *MergeSource) Sources() []EventSource {
+	sources := make([]EventSource, 0, len(m.inputs))
+	for _, in := range m.inputs {
+		sources = append(sources, in.source)
+	}
+	return sources
+}
+
+func (m *MergeSource) Name() string {
+	names := make([]string, 0, len(m.inputs))
+	for _, in := range m.inputs {
+		names = append(names, in.source.Name())
+	}
+	return "merge(" + strings.Join(names, ", ") + ")"
+}
+
+func (m *MergeSource) Stop() {
+	m.stream.Stop()
+	for _, in := range m.inputs {
+		in.source.Stop()
+	}
+}
+
+// Start waits for the head event of every source, so it emits
+// the next event only when all sources either have one or are exhausted.
+func (m *MergeSource) Start() {
+	for _, in := range m.inputs {
+		go in.source.Start()
+	}
+
+	defer func() {
+		if m.stopped() {
+			// Stopped sources are drained, so that they could finish.
+			for _, in := range m.inputs {
+				drain(in.source)
+			}
+		}
+		m.close()
+	}()
+
+	heads := &mergeHeap{}
+	for i := range m.inputs {
+		if !m.pull(heads, i) {
+			return
+		}
+	}
+
+	for heads.Len() > 0 {
+		head := heap.Pop(heads).(mergeHead)
+		if !m.send(head.event) {
+			return
+		}
+		if !m.pull(heads, head.input) {
+			return
+		}
+	}
+}
+
+// pull reads the next event of i-th source into heads. Errors reported
+// by the source before that event are passed on right away, as all events
+// of the source preceding them have been sent already.
+// It returns false if merge has been stopped.
+func (m *MergeSource) pull(heads *mergeHeap, i int) bool {
+	events, errs := m.inputs[i].source.Events(), m.inputs[i].source.Errors()
+	for {
+		select {
+		case e, ok := <-events:
+			if ok {
+				heap.Push(heads, mergeHead{event: e, input: i, priority: m.inputs[i].priority})
+			}
+			return true
+		case err, ok := <-errs:
+			if !ok {
+				errs = nil
+				continue
+			}
+			if !m.fail(err) {
+				return false
+			}
+		case <-m.done:
+			return false
+		}
+	}
+}
+
+type mergeHead struct {
+	event    Event
+	input    int
+	priority int
+}
+
+type mergeHeap []mergeHead
+
+func (h mergeHeap) Len() int { return len(h) }
+
+func (h mergeHeap) Less(i, j int) bool {
+	a, b := h[i], h[j]
+	if !a.event.TimeStamp.Equal(b.event.TimeStamp) {
+		return a.event.TimeStamp.Before(b.event.TimeStamp)
+	}
+	if a.priority != b.priority {
+		return a.priority < b.priority
+	}
+	return a.input < b.input
+}
+
+func (h mergeHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+
+func (h *mergeHeap) Push(x any) { *h = append(*h, x.(mergeHead)) }
+
+func (h *mergeHeap) Pop() any {
+	old := *h
+	n := len(old)
+	x := old[n-1]
+	*h = old[:n-1]
+	return x
+}
@@ -0,0 +1,88 @@
+package biathlon
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMergeSource(t *testing.T) {
+	ranges := NewSliceSource("ranges", []Event{
+		{TimeStamp: at(9, 49, 31, 659), Type: ComeToFiringRange, CompetitorID: 1},
+		{TimeStamp: at(9, 49, 38, 339), Type: LeaveFiringRange, CompetitorID: 1},
+	})
+	start := NewSliceSource("start", []Event{
+		{TimeStamp: at(9, 30, 1, 5), Type: Start, CompetitorID: 1},
+		{TimeStamp: at(9, 49, 38, 339), Type: Start, CompetitorID: 2},
+	})
+	penalty := NewSliceSource("penalty", nil)
+
+	merge := NewMergeSource()
+	merge.Add(ranges, 1)
+	merge.Add(start, 0)
+	merge.Add(penalty, 0)
+
+	if name := merge.Name(); name != "merge(ranges, start, penalty)" {
+		t.Errorf("Name() = %q", name)
+	}
+
+	events, errs := collect(merge)
+	if len(errs) != 0 {
+		t.Errorf("unexpected errors %v", errs)
+	}
+	// Events with equal time are ordered by priority of their sources.
+	want := []eventType{Start, ComeToFiringRange, Start, LeaveFiringRange}
+	if len(events) != len(want) {
+		t.Fatalf("events = %+v, want %d events", events, len(want))
+	}
+	for i, e := range events {
+		if e.Type != want[i] {
+			t.Errorf("event %d = %+v, want type %d", i, e, want[i])
+		}
+	}
+}
+
+func TestMergeSourceErrorsOrder(t *testing.T) {
+	first := NewReaderSource("first", strings.NewReader(strings.Join([]string{
+		"[09:00:00.000] 1 1",
+		"[09:00:03.000] 1 3",
+		"bad line 1",
+	}, "\n")))
+	second := NewReaderSource("second", strings.NewReader(strings.Join([]string{
+		"[09:00:01.000] 1 2",
+		"bad line 2",
+		"[09:00:04.000] 1 4",
+	}, "\n")))
+
+	merge := NewMergeSource()
+	merge.Add(first, 0)
+	merge.Add(second, 0)
+
+	lines := processLines(t, Config{}, merge)
+
+	// Error of a source is logged after events it follows in the source.
+	want := []string{
+		"[09:00:00.000] The competitor(1) registered",
+		"[09:00:01.000] The competitor(2) registered",
+		"error: second: ",
+		"[09:00:03.000] The competitor(3) registered",
+		"error: first: ",
+		"[09:00:04.000] The competitor(4) registered",
+	}
+	expectLines(t, lines, want)
+}
+
+func TestMergeSourceStop(t *testing.T) {
+	first := NewSliceSource("first", []Event{{TimeStamp: at(9, 0, 0, 0)}, {TimeStamp: at(9, 0, 2, 0)}})
+	second := NewSliceSource("second", []Event{{TimeStamp: at(9, 0, 1, 0)}})
+
+	merge := NewMergeSource()
+	merge.Add(first, 0)
+	merge.Add(second, 0)
+	go merge.Start()
+
+	nextEvent(t, merge.Events())
+	merge.Stop()
+	expectClosed(t, merge.Events())
+	expectClosed(t, first.Events())
+	expectClosed(t, second.Events())
+}
@@ -0,0 +1,119 @@
+package biathlon
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+var ErrTimeGoesBack = errors.New("event time goes back")
+
+// TimePolicy tells what to do with event which is earlier than the previous one.
+type TimePolicy int
+
+const (
+	// WarnTime reports event and passes it as it is.
+	WarnTime TimePolicy = iota
+	// RejectTime reports event and drops it.
+	RejectTime
+	// ClampTime reports event and moves it to the time of the previous one.
+	ClampTime
+)
+
+func ParseTimePolicy(s string) (TimePolicy, error) {
+	switch s {
+	case "warn":
+		return WarnTime, nil
+	case "reject":
+		return RejectTime, nil
+	case "clamp":
+		return ClampTime, nil
+	default:
+		return 0, fmt.Errorf("unknown time policy %q: warn, reject or clamp expected", s)
+	}
+}
+
+func (p TimePolicy) String() string {
+	switch p {
+	case WarnTime:
+		return "warn"
+	case RejectTime:
+		return "reject"
+	case ClampTime:
+		return "clamp"
+	default:
+		return fmt.Sprintf("TimePolicy(%d)", int(p))
+	}
+}
+
+// MonotonicSource checks that event times of the wrapped source
+// don't decrease. Every violation is reported as an error and handled
+// according to the policy. Summary of violations is reported at the end.
+type MonotonicSource struct {
+	wrapper
+	policy TimePolicy
+
+	last       time.Time
+	started    bool
+	violations int
+	maxBack    time.Duration
+}
+
+func NewMonotonicSource(source EventSource, policy TimePolicy) *MonotonicSource {
+	return &MonotonicSource{
+		wrapper: newWrapper(source),
+		policy:  policy,
+	}
+}
+
+func (m *MonotonicSource) Start() {
+	m.pipe(m.check, nil, m.summarize)
+}
+
+func (m *MonotonicSource) summarize() {
+	if m.violations > 0 {
+		m.fail(fmt.Errorf(
+			"%w: %d events are out of order (policy %s), the farthest by %s",
+			ErrTimeGoesBack,
+			m.violations,
+			m.policy,
+			m.maxBack,
+		))
+	}
+}
+
+// check handles event according to the policy. It returns false if source is stopped.
+func (m *MonotonicSource) check(e Event) bool {
+	if m.started && e.TimeStamp.Before(m.last) {
+		back := m.last.Sub(e.TimeStamp)
+		m.violations++
+		m.maxBack = max(m.maxBack, back)
+
+		err := fmt.Errorf(
+			"%w: event %d of competitor(%d) at %s is %s earlier than previous one (%s)",
+			ErrTimeGoesBack,
+			e.Type,
+			e.CompetitorID,
+			formatEventTime(e.TimeStamp),
+			back,
+			m.policy,
+		)
+		if !m.fail(&EventError{Event: e, Err: err}) {
+			return false
+		}
+
+		switch m.policy {
+		case RejectTime:
+			return true
+		case ClampTime:
+			e.TimeStamp = m.last
+		}
+	}
+
+	if !m.started || e.TimeStamp.After(m.last) {
+		m.last = e.TimeStamp
+		m.started = true
+	}
+
+	return m.send(e)
+}
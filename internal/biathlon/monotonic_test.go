@@ -0,0 +1,100 @@
+package biathlon
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMonotonicSource(t *testing.T) {
+	events := []Event{
+		{TimeStamp: at(9, 0, 2, 0), CompetitorID: 1},
+		{TimeStamp: at(9, 0, 1, 0), CompetitorID: 2},
+		{TimeStamp: at(9, 0, 3, 0), CompetitorID: 3},
+		{TimeStamp: at(9, 0, 0, 0), CompetitorID: 4},
+	}
+
+	tests := []struct {
+		policy TimePolicy
+		want   []Event
+	}{
+		{WarnTime, events},
+		{RejectTime, []Event{events[0], events[2]}},
+		{ClampTime, []Event{
+			events[0],
+			{TimeStamp: at(9, 0, 2, 0), CompetitorID: 2},
+			events[2],
+			{TimeStamp: at(9, 0, 3, 0), CompetitorID: 4},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.policy.String(), func(t *testing.T) {
+			got, errs := collect(NewMonotonicSource(NewSliceSource("slice", events), tt.policy))
+
+			if len(got) != len(tt.want) {
+				t.Fatalf("events = %+v, want %+v", got, tt.want)
+			}
+			for i := range got {
+				if !got[i].TimeStamp.Equal(tt.want[i].TimeStamp) || got[i].CompetitorID != tt.want[i].CompetitorID {
+					t.Errorf("event %d = %+v, want %+v", i, got[i], tt.want[i])
+				}
+			}
+
+			// Two violations and the summary.
+			if len(errs) != 3 {
+				t.Fatalf("errs = %v, want 3 errors", errs)
+			}
+			for _, err := range errs {
+				if !errors.Is(err, ErrTimeGoesBack) {
+					t.Errorf("error %v isn't ErrTimeGoesBack", err)
+				}
+			}
+			if summary := errs[2].Error(); !strings.Contains(summary, "2 events are out of order") ||
+				!strings.HasSuffix(summary, "the farthest by 3s") {
+				t.Errorf("summary = %q", summary)
+			}
+		})
+	}
+}
+
+func TestMonotonicSourceSummaryWhenInputStops(t *testing.T) {
+	input := newPushSource()
+	monotonic := NewMonotonicSource(input, WarnTime)
+
+	done := make(chan []string)
+	go func() {
+		done <- processLines(t, Config{}, monotonic)
+	}()
+
+	input.push(t,
+		Event{TimeStamp: at(9, 0, 2, 0), Type: Register, CompetitorID: 1},
+		Event{TimeStamp: at(9, 0, 1, 0), Type: Register, CompetitorID: 2},
+	)
+	input.Stop()
+
+	select {
+	case lines := <-done:
+		expectLines(t, lines, []string{
+			"[09:00:02.000] The competitor(1) registered",
+			"error: event time goes back: event 1 of competitor(2)",
+			"[09:00:01.000] The competitor(2) registered",
+			"error: event time goes back: 1 events are out of order (policy warn), the farthest by 1s",
+		})
+	case <-time.After(time.Second):
+		t.Fatal("processor hasn't finished in time")
+	}
+}
+
+func TestParseTimePolicy(t *testing.T) {
+	for _, policy := range []TimePolicy{WarnTime, RejectTime, ClampTime} {
+		got, err := ParseTimePolicy(policy.String())
+		if err != nil || got != policy {
+			t.Errorf("ParseTimePolicy(%q) = %v, %v", policy, got, err)
+		}
+	}
+	if _, err := ParseTimePolicy("ignore"); err == nil {
+		t.Error("ParseTimePolicy(\"ignore\") succeeded")
+	}
+}
@@ -2,7 +2,9 @@ package biathlon
 
 import (
 	"errors"
+	"fmt"
 	"os"
+	"slices"
 	"time"
 )
 
@@ -14,8 +16,13 @@ var (
 type EventHandler func(e Event)
 
 type Processor struct {
-	events      <-chan Event
+	source      EventSource
 	eventsQueue []Event
+	// generated counts events made by processor to skip them when
+	// they come from a replayed log of a previous run. pending keeps
+	// the same keys in order to forget the ones the input has passed.
+	generated   map[generatedKey]int
+	pending     []generatedKey
 	competitors map[int]CompetitorState
 
 	fsm FSM
@@ -27,10 +34,11 @@ type Processor struct {
 	log Logger
 }
 
-func NewProcessor(conf Config, events <-chan Event) *Processor {
+func NewProcessor(conf Config, source EventSource) *Processor {
 	return &Processor{
-		events:      events,
+		source:      source,
 		competitors: make(map[int]CompetitorState),
+		generated:   make(map[generatedKey]int),
 		fsm:         initBiathlonFSM(conf),
 		config:      conf,
 		handlers:    make(map[eventType]EventHandler),
@@ -42,16 +50,44 @@ func (p *Processor) SetLogger(log Logger) {
 	p.log = log
 }
 
+// Start runs event source and processes its events until it's exhausted.
+// Errors reported by the source are logged along the way.
 func (p *Processor) Start() {
 	var lastTime time.Time
 
+	go p.source.Start()
+	events, errs := p.source.Events(), p.source.Errors()
+
 	for {
 		if len(p.eventsQueue) == 0 {
-			e, ok := <-p.events
-			if !ok {
+			if events == nil && errs == nil {
 				break
 			}
-			p.eventsQueue = append(p.eventsQueue, e)
+
+			select {
+			case e, ok := <-events:
+				if !ok {
+					events = nil
+					continue
+				}
+				if p.consumeGenerated(e) {
+					continue
+				}
+				p.eventsQueue = append(p.eventsQueue, e)
+			case err, ok := <-errs:
+				if !ok {
+					errs = nil
+					continue
+				}
+
+				ts := lastTime
+				var evErr *EventError
+				if errors.As(err, &evErr) {
+					ts = evErr.Event.TimeStamp
+				}
+				p.log.Error(ts, err)
+				continue
+			}
 		}
 		e := p.eventsQueue[0]
 		p.eventsQueue = p.eventsQueue[1:]
@@ -74,6 +110,52 @@ func (p *Processor) Handle(e eventType, handler EventHandler) {
 	p.handlers[e] = handler
 }
 
+// generatedKey identifies generated event, they have no parameters.
+type generatedKey struct {
+	competitorID int
+	event        eventType
+	timeStamp    time.Time
+}
+
+func keyOf(e Event) generatedKey {
+	return generatedKey{
+		competitorID: e.CompetitorID,
+		event:        e.Type,
+		timeStamp:    e.TimeStamp,
+	}
+}
+
+func (p *Processor) addGenerated(e Event) {
+	key := keyOf(e)
+	p.generated[key]++
+	p.pending = append(p.pending, key)
+}
+
+// consumeGenerated reports whether e is a replayed copy of generated event.
+// Generated events take the time of the event caused them, so the ones
+// older than e won't be replayed anymore and are forgotten.
+func (p *Processor) consumeGenerated(e Event) bool {
+	for len(p.pending) > 0 && p.pending[0].timeStamp.Before(e.TimeStamp) {
+		p.forget(p.pending[0])
+		p.pending = p.pending[1:]
+	}
+
+	key := keyOf(e)
+	if p.generated[key] == 0 {
+		return false
+	}
+	p.forget(key)
+	return true
+}
+
+func (p *Processor) forget(key generatedKey) {
+	if p.generated[key] <= 1 {
+		delete(p.generated, key)
+		return
+	}
+	p.generated[key]--
+}
+
 func (p *Processor) processEvent(e Event) error {
 	cID := e.CompetitorID
 	competitor := p.competitors[cID]
@@ -88,6 +170,9 @@ func (p *Processor) processEvent(e Event) error {
 		if err != nil {
 			return err
 		}
+		for _, ge := range generatedEvents {
+			p.addGenerated(ge)
+		}
 		p.eventsQueue = append(p.eventsQueue, generatedEvents...)
 	}
 
@@ -105,11 +190,15 @@ func (p *Processor) processEvent(e Event) error {
 func (p *Processor) finalize(lastTime time.Time) {
 	for cID, c := range p.competitors {
 		status, cb, ok := p.fsm.LookupPath(c.Status, Disqualify)
-		if !ok || c.Status == Finished {
+		if !ok || c.Status == Finished || c.Status == Relayed {
 			continue
 		}
 
-		disqualify := Event{TimeStamp: lastTime, Type: Disqualify, CompetitorID: cID}
+		code := DidNotFinish
+		if c.Status == Registered || c.Status == Scheduled || c.Status == OnStartLine {
+			code = DidNotStart
+		}
+		disqualify := disqualification(Event{TimeStamp: lastTime, CompetitorID: cID}, code, "")
 		if cb != nil {
 			_, err := cb(disqualify, &c)
 			if err != nil {
@@ -130,159 +219,346 @@ func (p *Processor) finalize(lastTime time.Time) {
 	}
 }
 
-func initBiathlonFSM(conf Config) FSM {
-	return NewFSM(
-		[]Edge{
-			{
-				Src: Unknown,
-				Dst: Registered, Event: Register,
-				Cb: func(_ Event, c *CompetitorState) ([]Event, error) {
-					c.VisitedRanges = make([]bool, conf.FiringLines)
-					return []Event{}, nil
-				},
+// disqualification generates Disqualify event for the competitor of e.
+func disqualification(e Event, code ReasonCode, detail string) Event {
+	return Event{
+		TimeStamp:    e.TimeStamp,
+		Type:         Disqualify,
+		CompetitorID: e.CompetitorID,
+		ExtraParams:  []any{Reason{Code: code, Detail: detail}},
+	}
+}
+
+// relayEdges lets competitor finished the leg tag over the next member
+// of the team, who starts the leg right away.
+func relayEdges(conf Config) []Edge {
+	return []Edge{
+		{
+			Src:   Finished,
+			Dst:   Relayed,
+			Event: TagOver,
+			Cb: func(e Event, c *CompetitorState) ([]Event, error) {
+				team, leg, _ := conf.TeamLeg(e.CompetitorID)
+				if leg >= len(team.Members) {
+					// The last leg has no one to tag over.
+					return []Event{}, ErrWrongEventsSequence
+				}
+
+				startLeg := Event{
+					TimeStamp:    e.TimeStamp,
+					Type:         StartLeg,
+					CompetitorID: team.Members[leg],
+					ExtraParams:  []any{e.CompetitorID},
+				}
+				return []Event{startLeg}, nil
 			},
+		},
+		{Src: Relayed, Dst: Disqualified, Event: Disqualify},
+
+		{
+			Src:   Registered,
+			Dst:   OnMainLap,
+			Event: StartLeg,
+			Cb: func(e Event, c *CompetitorState) ([]Event, error) {
+				c.ActualStartTime = e.TimeStamp
+				c.CurrentLap = 1
+				return []Event{}, nil
+			},
+		},
+	}
+}
 
-			{
-				Src:   Registered,
-				Dst:   Scheduled,
-				Event: BeSheduled,
-				Cb: func(e Event, c *CompetitorState) ([]Event, error) {
-					startTime := e.ExtraParams[0].(time.Time)
-					c.ScheduledStartTime = startTime
+// skippedLoops generates sanction for penalty loops competitor hasn't skied:
+// either Disqualify event or SkipPenaltyLoops one adding time penalty.
+func skippedLoops(conf Config, e Event, loops int) Event {
+	if conf.PenaltySanction == DisqualifySanction {
+		return disqualification(e, PenaltySkipped, fmt.Sprintf("%d loops", loops))
+	}
+	return Event{
+		TimeStamp:    e.TimeStamp,
+		Type:         SkipPenaltyLoops,
+		CompetitorID: e.CompetitorID,
+		ExtraParams:  []any{loops},
+	}
+}
 
-					return []Event{}, nil
-				},
-			},
-			{Src: Registered, Dst: NotStarted, Event: Disqualify},
-			{Src: Registered, Dst: CannotContinue, Event: BeUnableToContinue}, // ???
-
-			{
-				Src:   Scheduled,
-				Dst:   OnStartLine,
-				Event: ComeToStartLine,
-				Cb: func(e Event, c *CompetitorState) ([]Event, error) {
-					scheduledTime := c.ScheduledStartTime
-					threshold := scheduledTime.Add(time.Duration(conf.StartDelta))
-					if e.TimeStamp.After(threshold) {
-						disqualify := Event{
-							TimeStamp: e.TimeStamp, Type: Disqualify, CompetitorID: e.CompetitorID,
-						}
-						return []Event{disqualify}, nil
-					}
+// scheduling generates BeSheduled event setting start time of the competitor of e.
+func scheduling(e Event, startTime time.Time) Event {
+	return Event{
+		TimeStamp:    e.TimeStamp,
+		Type:         BeSheduled,
+		CompetitorID: e.CompetitorID,
+		ExtraParams:  []any{startTime},
+	}
+}
 
-					return []Event{}, nil
-				},
-			},
-			{Src: Scheduled, Dst: NotStarted, Event: Disqualify},
-			{Src: Scheduled, Dst: CannotContinue, Event: BeUnableToContinue}, // ???
-
-			{
-				Src:   OnStartLine,
-				Dst:   OnMainLap,
-				Event: Start,
-				Cb: func(e Event, c *CompetitorState) ([]Event, error) {
-					scheduledTime := c.ScheduledStartTime
-					threshold := scheduledTime.Add(time.Duration(conf.StartDelta))
-					if e.TimeStamp.After(threshold) {
-						disqualify := Event{
-							TimeStamp: e.TimeStamp, Type: Disqualify, CompetitorID: e.CompetitorID,
-						}
-						return []Event{disqualify}, nil
-					} else {
-						c.ActualStartTime = e.TimeStamp
-						c.CurrentLap = 1
+func initBiathlonFSM(conf Config) FSM {
+	edges := []Edge{
+		{
+			Src: Unknown,
+			Dst: Registered, Event: Register,
+			Cb: func(e Event, c *CompetitorState) ([]Event, error) {
+				c.VisitedRanges = make([]bool, conf.FiringLines)
+
+				switch conf.RaceFormat() {
+				case Pursuit:
+					// Start of pursuit is set by results of the previous race instead of a draw.
+					gap, ok := conf.StartList[e.CompetitorID]
+					if !ok {
+						return []Event{disqualification(e, NotEntered, "not in the start list")}, nil
 					}
-
-					return []Event{}, nil
-				},
-			},
-			{Src: OnStartLine, Dst: NotStarted, Event: Disqualify},
-			{Src: OnStartLine, Dst: CannotContinue, Event: BeUnableToContinue}, // ???
-
-			{
-				Src:   OnMainLap,
-				Dst:   OnRange,
-				Event: ComeToFiringRange,
-				Cb: func(e Event, c *CompetitorState) ([]Event, error) {
-					firingRange := e.ExtraParams[0].(int)
-					if firingRange < 1 || firingRange > conf.FiringLines {
-						return []Event{}, ErrInvalidParamValue
+					return []Event{scheduling(e, conf.StartTime().Add(gap))}, nil
+				case MassStart:
+					return []Event{scheduling(e, conf.StartTime())}, nil
+				case Relay:
+					team, leg, ok := conf.TeamLeg(e.CompetitorID)
+					if !ok {
+						return []Event{disqualification(e, NotEntered, "not in any team")}, nil
 					}
-					if c.VisitedRanges[firingRange-1] {
-						disqualify := Event{
-							TimeStamp: e.TimeStamp, Type: Disqualify, CompetitorID: e.CompetitorID,
-						}
-						return []Event{disqualify}, nil
-					} else {
-						c.VisitedRanges[firingRange-1] = true
+					c.Team, c.Leg = team.Name, leg
+					// Next legs start when the previous member tags over.
+					if leg == 1 {
+						return []Event{scheduling(e, conf.StartTime())}, nil
 					}
+				}
 
-					return []Event{}, nil
-				},
+				return []Event{}, nil
 			},
-			{Src: OnMainLap, Dst: OnPenaltyLap, Event: EnterPenaltyLap},
-			{
-				Src:   OnMainLap,
-				Dst:   OnMainLap,
-				Event: EndMainLap,
-				Cb: func(e Event, c *CompetitorState) ([]Event, error) {
-					if c.CurrentLap < conf.Laps {
-						c.CurrentLap++
-						c.HitsThisRange = [5]bool{}
-					} else {
-						finish := Event{TimeStamp: e.TimeStamp, Type: Finish, CompetitorID: e.CompetitorID}
-						return []Event{finish}, nil
-					}
+		},
+
+		{
+			Src:   Registered,
+			Dst:   Scheduled,
+			Event: BeSheduled,
+			Cb: func(e Event, c *CompetitorState) ([]Event, error) {
+				startTime := e.ExtraParams[0].(time.Time)
+				c.ScheduledStartTime = startTime
 
-					return []Event{}, nil
-				},
+				return []Event{}, nil
 			},
-			{
-				Src:   OnMainLap,
-				Dst:   Finished,
-				Event: Finish,
-				Cb: func(e Event, c *CompetitorState) ([]Event, error) {
-					for _, visited := range c.VisitedRanges {
-						if !visited {
-							disqualify := Event{
-								TimeStamp: e.TimeStamp, Type: Disqualify, CompetitorID: e.CompetitorID,
-							}
-							return []Event{disqualify}, nil
-						}
+		},
+		{Src: Registered, Dst: NotStarted, Event: Disqualify},
+		{Src: Registered, Dst: CannotContinue, Event: BeUnableToContinue}, // ???
+
+		{
+			Src:   Scheduled,
+			Dst:   OnStartLine,
+			Event: ComeToStartLine,
+			Cb: func(e Event, c *CompetitorState) ([]Event, error) {
+				_, latest := conf.StartWindow(c.ScheduledStartTime)
+				if e.TimeStamp.After(latest) {
+					return []Event{disqualification(e, LateStart, "")}, nil
+				}
+
+				return []Event{}, nil
+			},
+		},
+		{Src: Scheduled, Dst: NotStarted, Event: Disqualify},
+		{Src: Scheduled, Dst: CannotContinue, Event: BeUnableToContinue}, // ???
+
+		{
+			Src:   OnStartLine,
+			Dst:   OnMainLap,
+			Event: Start,
+			Cb: func(e Event, c *CompetitorState) ([]Event, error) {
+				earliest, latest := conf.StartWindow(c.ScheduledStartTime)
+				if e.TimeStamp.After(latest) {
+					return []Event{disqualification(e, LateStart, "")}, nil
+				} else if !earliest.IsZero() && e.TimeStamp.Before(earliest) {
+					return []Event{disqualification(e, FalseStart, "")}, nil
+				} else {
+					c.ActualStartTime = e.TimeStamp
+					c.CurrentLap = 1
+				}
+
+				return []Event{}, nil
+			},
+		},
+		{Src: OnStartLine, Dst: NotStarted, Event: Disqualify},
+		{Src: OnStartLine, Dst: CannotContinue, Event: BeUnableToContinue}, // ???
+
+		{
+			Src:   OnMainLap,
+			Dst:   OnRange,
+			Event: ComeToFiringRange,
+			Cb: func(e Event, c *CompetitorState) ([]Event, error) {
+				firingRange := e.ExtraParams[0].(int)
+				if firingRange < 1 || firingRange > conf.FiringLines {
+					return []Event{}, ErrInvalidParamValue
+				}
+				if slot, ok := conf.ScheduledRange(c.CurrentLap); len(conf.RangeSchedule) > 0 && !ok {
+					detail := fmt.Sprintf("no shooting on lap %d", c.CurrentLap)
+					return []Event{disqualification(e, WrongRange, detail)}, nil
+				} else if ok && slot.Range != firingRange {
+					detail := fmt.Sprintf(
+						"range(%d) instead of range(%d) on lap %d",
+						firingRange,
+						slot.Range,
+						c.CurrentLap,
+					)
+					return []Event{disqualification(e, WrongRange, detail)}, nil
+				}
+				if c.VisitedRanges[firingRange-1] {
+					detail := fmt.Sprintf("range(%d)", firingRange)
+					return []Event{disqualification(e, RangeRevisited, detail)}, nil
+				} else {
+					c.VisitedRanges[firingRange-1] = true
+				}
+				c.CurrentRange = firingRange
+				c.HitsThisRange = make([]bool, conf.ShotsAt(firingRange))
+				c.SparesThisRange = 0
+
+				return []Event{}, nil
+			},
+		},
+		{
+			Src:   OnMainLap,
+			Dst:   OnPenaltyLap,
+			Event: EnterPenaltyLap,
+			Cb: func(e Event, c *CompetitorState) ([]Event, error) {
+				if c.LoopsOwed == 0 {
+					return []Event{}, fmt.Errorf("%w: no penalty loops are owed", ErrWrongEventsSequence)
+				}
+				c.PenaltyEntry = e.TimeStamp
+
+				return []Event{}, nil
+			},
+		},
+		{Src: OnMainLap, Dst: OnMainLap, Event: SkipPenaltyLoops},
+		{
+			Src:   OnMainLap,
+			Dst:   OnMainLap,
+			Event: EndMainLap,
+			Cb: func(e Event, c *CompetitorState) ([]Event, error) {
+				events := []Event{}
+				// Loops still owed at the end of the lap were never skied.
+				if c.LoopsOwed > 0 {
+					sanction := skippedLoops(conf, e, c.LoopsOwed)
+					c.LoopsOwed = 0
+					if sanction.Type == Disqualify {
+						return []Event{sanction}, nil
 					}
-					return []Event{}, nil
-				},
+					events = append(events, sanction)
+				}
+
+				if c.CurrentLap < conf.Laps {
+					c.CurrentLap++
+					c.HitsThisRange = nil
+				} else {
+					finish := Event{TimeStamp: e.TimeStamp, Type: Finish, CompetitorID: e.CompetitorID}
+					events = append(events, finish)
+				}
+
+				return events, nil
 			},
-			{Src: OnMainLap, Dst: Disqualified, Event: Disqualify},
-			{Src: OnMainLap, Dst: CannotContinue, Event: BeUnableToContinue}, // ???
-
-			{
-				Src:   OnRange,
-				Dst:   OnRange,
-				Event: HitTarget,
-				Cb: func(e Event, c *CompetitorState) ([]Event, error) {
-					target := e.ExtraParams[0].(int)
-					if target < 1 || target > 5 {
-						return []Event{}, ErrInvalidParamValue
+		},
+		{
+			Src:   OnMainLap,
+			Dst:   Finished,
+			Event: Finish,
+			Cb: func(e Event, c *CompetitorState) ([]Event, error) {
+				for i, visited := range c.VisitedRanges {
+					if !visited {
+						detail := fmt.Sprintf("range(%d)", i+1)
+						return []Event{disqualification(e, RangesMissed, detail)}, nil
 					}
-					if c.HitsThisRange[target-1] {
-						return []Event{}, ErrWrongEventsSequence
-					} else {
-						c.HitsThisRange[target-1] = true
+				}
+				return []Event{}, nil
+			},
+		},
+		{Src: OnMainLap, Dst: Disqualified, Event: Disqualify},
+		{Src: OnMainLap, Dst: CannotContinue, Event: BeUnableToContinue}, // ???
+
+		{
+			Src:   OnRange,
+			Dst:   OnRange,
+			Event: HitTarget,
+			Cb: func(e Event, c *CompetitorState) ([]Event, error) {
+				target := e.ExtraParams[0].(int)
+				if target < 1 || target > len(c.HitsThisRange) {
+					return []Event{}, ErrInvalidParamValue
+				}
+				if c.HitsThisRange[target-1] {
+					return []Event{}, ErrWrongEventsSequence
+				} else {
+					c.HitsThisRange[target-1] = true
+				}
+
+				return []Event{}, nil
+			},
+		},
+		{
+			Src:   OnRange,
+			Dst:   OnRange,
+			Event: LoadSpare,
+			Cb: func(e Event, c *CompetitorState) ([]Event, error) {
+				if conf.SpareRounds == 0 {
+					return []Event{}, fmt.Errorf("%w: spare rounds are not allowed", ErrWrongEventsSequence)
+				}
+				if c.SparesThisRange >= conf.SpareRounds {
+					return []Event{}, fmt.Errorf(
+						"%w: all %d spare rounds are used",
+						ErrWrongEventsSequence,
+						conf.SpareRounds,
+					)
+				}
+				if !slices.Contains(c.HitsThisRange, false) {
+					return []Event{}, fmt.Errorf("%w: all targets are hit", ErrWrongEventsSequence)
+				}
+				c.SparesThisRange++
+
+				return []Event{}, nil
+			},
+		},
+		{
+			Src:   OnRange,
+			Dst:   OnMainLap,
+			Event: LeaveFiringRange,
+			Cb: func(e Event, c *CompetitorState) ([]Event, error) {
+				// Misses of Individual format are punished by time instead of loops.
+				if conf.RaceFormat() != Individual {
+					for _, hit := range c.HitsThisRange {
+						if !hit {
+							c.LoopsOwed++
+						}
 					}
+				}
 
-					return []Event{}, nil
-				},
+				return []Event{}, nil
+			},
+		},
+		{Src: OnRange, Dst: Disqualified, Event: Disqualify},
+		{Src: OnRange, Dst: CannotContinue, Event: BeUnableToContinue}, // ???
+
+		{
+			Src:   OnPenaltyLap,
+			Dst:   OnMainLap,
+			Event: LeavePenaltyLap,
+			Cb: func(e Event, c *CompetitorState) ([]Event, error) {
+				// Loops may be skied in several passes, the rest
+				// is sanctioned at the end of the lap.
+				skied := conf.LoopsSkied(e.TimeStamp.Sub(c.PenaltyEntry))
+				c.LoopsOwed = max(c.LoopsOwed-skied, 0)
+
+				return []Event{}, nil
 			},
-			{Src: OnRange, Dst: OnMainLap, Event: LeaveFiringRange},
-			{Src: OnRange, Dst: Disqualified, Event: Disqualify},
-			{Src: OnRange, Dst: CannotContinue, Event: BeUnableToContinue}, // ???
+		},
+		{Src: OnPenaltyLap, Dst: Disqualified, Event: Disqualify},
+		{Src: OnPenaltyLap, Dst: CannotContinue, Event: BeUnableToContinue}, // ???
+
+		{Src: Finished, Dst: Disqualified, Event: Disqualify},
+	}
 
-			{Src: OnPenaltyLap, Dst: OnMainLap, Event: LeavePenaltyLap},
-			{Src: OnPenaltyLap, Dst: Disqualified, Event: Disqualify},
-			{Src: OnPenaltyLap, Dst: CannotContinue, Event: BeUnableToContinue}, // ???
+	if conf.RaceFormat() == Relay {
+		edges = append(edges, relayEdges(conf)...)
+	}
+
+	if conf.RaceFormat() == Individual {
+		// Misses are punished by time, so there are no penalty laps.
+		edges = slices.DeleteFunc(edges, func(e Edge) bool {
+			return e.Event == EnterPenaltyLap || e.Event == SkipPenaltyLoops || e.Src == OnPenaltyLap
+		})
+	}
 
-			{Src: Finished, Dst: Disqualified, Event: Disqualify},
-		}...,
-	)
+	return NewFSM(edges...)
 }
@@ -0,0 +1,470 @@
+package biathlon
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// recordingLogger keeps processed events and errors.
+type recordingLogger struct {
+	events []Event
+	errs   []error
+	// lines holds events and errors in order they were logged.
+	lines []string
+}
+
+func (l *recordingLogger) Event(e Event) {
+	l.events = append(l.events, e)
+	l.lines = append(l.lines, strings.TrimSpace((&DefaultLogger{}).msgFromEvent(e)))
+}
+
+func (l *recordingLogger) Error(_ time.Time, err error) {
+	l.errs = append(l.errs, err)
+	l.lines = append(l.lines, "error: "+err.Error())
+}
+
+// processLines runs processor over source and returns logged lines.
+func processLines(t *testing.T, conf Config, source EventSource) []string {
+	t.Helper()
+
+	log := &recordingLogger{}
+	p := NewProcessor(conf, source)
+	p.SetLogger(log)
+	p.Start()
+	return log.lines
+}
+
+// expectLines checks that lines start with want ones.
+func expectLines(t *testing.T, lines, want []string) {
+	t.Helper()
+
+	if len(lines) < len(want) {
+		t.Fatalf("logged %q, want at least %d lines", lines, len(want))
+	}
+	for i, line := range lines[:len(want)] {
+		if !strings.HasPrefix(line, want[i]) {
+			t.Errorf("line %d = %q, want %q...", i, line, want[i])
+		}
+	}
+}
+
+func TestProcessorLogsErrorsInOrder(t *testing.T) {
+	input := strings.Join([]string{
+		"[09:05:59.867] 1 1",
+		"[09:05:59.900] one 2",
+		"[09:06:00.000] 1 3",
+		"[09:06:01.000] 1",
+	}, "\n")
+
+	lines := processLines(t, Config{}, NewReaderSource("input", strings.NewReader(input)))
+
+	want := []string{
+		"[09:05:59.867] The competitor(1) registered",
+		"error: input: ",
+		"[09:06:00.000] The competitor(3) registered",
+		"error: input: ",
+	}
+	expectLines(t, lines, want)
+}
+
+func TestProcessorShotsPerRange(t *testing.T) {
+	input := strings.Join([]string{
+		"[09:00:00.000] 1 1",
+		"[09:00:01.000] 2 1 09:30:00.000",
+		"[09:29:00.000] 3 1",
+		"[09:30:00.000] 4 1",
+		"[09:35:00.000] 5 1 2",
+		"[09:35:10.000] 6 1 3",
+		"[09:35:20.000] 6 1 4",
+	}, "\n")
+	conf := Config{
+		Laps:          2,
+		FiringLines:   2,
+		ShotsPerRange: oneOrMany[int]{5, 3},
+		StartDelta:    duration(30 * time.Second),
+	}
+
+	lines := processLines(t, conf, NewReaderSource("input", strings.NewReader(input)))
+
+	// There are only 3 targets on the second range.
+	want := []string{
+		"[09:00:00.000] The competitor(1) registered",
+		"[09:00:01.000] The start time for the competitor(1) was set by a draw to 09:30:00.000",
+		"[09:29:00.000] The competitor(1) is on the start line",
+		"[09:30:00.000] The competitor(1) has started",
+		"[09:35:00.000] The competitor(1) is on the firing range(2)",
+		"[09:35:10.000] The target(3) has been hit by competitor(1)",
+		"error: " + ErrInvalidParamValue.Error(),
+	}
+	expectLines(t, lines, want)
+}
+
+func TestProcessorRangeSchedule(t *testing.T) {
+	conf := Config{
+		Laps:        2,
+		FiringLines: 2,
+		RangeSchedule: []RangeSlot{
+			{Lap: 1, Range: 1, Position: Prone},
+			{Lap: 2, Range: 2, Position: Standing},
+		},
+		StartDelta: duration(30 * time.Second),
+	}
+	start := []string{
+		"[09:00:00.000] 1 1",
+		"[09:00:01.000] 2 1 09:30:00.000",
+		"[09:29:00.000] 3 1",
+		"[09:30:00.000] 4 1",
+	}
+
+	tests := []struct {
+		name   string
+		events []string
+		want   string
+	}{
+		{
+			"wrong range on the first lap",
+			[]string{"[09:35:00.000] 5 1 2"},
+			"[09:35:00.000] The competitor(1) is disqualified: out of range schedule (range(2) instead of range(1) on lap 1)",
+		},
+		{
+			"wrong range on the second lap",
+			[]string{"[09:35:00.000] 5 1 1", "[09:36:00.000] 7 1", "[09:40:00.000] 10 1", "[09:45:00.000] 5 1 1"},
+			"[09:45:00.000] The competitor(1) is disqualified: out of range schedule (range(1) instead of range(2) on lap 2)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input := strings.Join(append(slices.Clone(start), tt.events...), "\n")
+			lines := processLines(t, conf, NewReaderSource("input", strings.NewReader(input)))
+
+			if !slices.Contains(lines, tt.want) {
+				t.Errorf("logged %q, want %q", lines, tt.want)
+			}
+		})
+	}
+}
+
+func TestProcessorIndividualHasNoPenaltyLaps(t *testing.T) {
+	input := strings.Join([]string{
+		"[09:00:00.000] 1 1",
+		"[09:00:01.000] 2 1 09:30:00.000",
+		"[09:29:00.000] 3 1",
+		"[09:30:00.000] 4 1",
+		"[09:35:00.000] 5 1 1",
+		"[09:36:00.000] 7 1",
+		"[09:36:10.000] 8 1",
+	}, "\n")
+	conf := Config{
+		Format:      Individual,
+		Laps:        2,
+		FiringLines: 1,
+		StartDelta:  duration(30 * time.Second),
+	}
+
+	lines := processLines(t, conf, NewReaderSource("input", strings.NewReader(input)))
+
+	if len(lines) < 7 || lines[6] != "error: "+ErrWrongEventsSequence.Error() {
+		t.Errorf("logged %q, want penalty lap to be rejected", lines)
+	}
+}
+
+func TestProcessorPursuitStartList(t *testing.T) {
+	input := strings.Join([]string{
+		"[09:00:00.000] 1 1",
+		"[09:00:00.000] 1 2",
+		"[09:00:00.000] 1 3",
+		"[09:29:00.000] 3 1",
+		"[09:29:30.000] 3 2",
+		"[09:30:00.000] 4 1",
+		// The second competitor starts 5s before the gap behind the first one.
+		"[09:30:25.000] 4 2",
+	}, "\n")
+	conf := Config{
+		Format:      Pursuit,
+		Laps:        1,
+		FiringLines: 1,
+		Start:       justTime(at(9, 30, 0, 0)),
+		StartList:   map[int]time.Duration{1: 0, 2: 30 * time.Second},
+	}
+
+	lines := processLines(t, conf, NewReaderSource("input", strings.NewReader(input)))
+
+	for _, want := range []string{
+		"[09:00:00.000] The start time for the competitor(1) was set by a draw to 09:30:00.000",
+		"[09:00:00.000] The start time for the competitor(2) was set by a draw to 09:30:30.000",
+		"[09:00:00.000] The competitor(3) is disqualified: not entered (not in the start list)",
+		"[09:30:00.000] The competitor(1) has started",
+		"[09:30:25.000] The competitor(2) is disqualified: false start",
+	} {
+		if !slices.Contains(lines, want) {
+			t.Errorf("missing %q in %q", want, lines)
+		}
+	}
+}
+
+func TestProcessorMassStart(t *testing.T) {
+	input := strings.Join([]string{
+		"[09:00:00.000] 1 1",
+		"[09:00:00.000] 1 2",
+		"[09:00:00.000] 1 3",
+		"[09:29:00.000] 3 1",
+		"[09:29:00.000] 3 2",
+		"[09:29:00.000] 3 3",
+		"[09:29:58.000] 4 1",
+		"[09:30:00.500] 4 2",
+		"[09:30:02.000] 4 3",
+	}, "\n")
+	conf := Config{
+		Format:      MassStart,
+		Laps:        1,
+		FiringLines: 1,
+		Start:       justTime(at(9, 30, 0, 0)),
+	}
+
+	lines := processLines(t, conf, NewReaderSource("input", strings.NewReader(input)))
+
+	for _, want := range []string{
+		"[09:00:00.000] The start time for the competitor(3) was set by a draw to 09:30:00.000",
+		"[09:29:58.000] The competitor(1) is disqualified: false start",
+		"[09:30:00.500] The competitor(2) has started",
+		"[09:30:02.000] The competitor(3) is disqualified: late start",
+	} {
+		if !slices.Contains(lines, want) {
+			t.Errorf("missing %q in %q", want, lines)
+		}
+	}
+}
+
+func TestProcessorRelay(t *testing.T) {
+	input := strings.Join([]string{
+		"[09:00:00.000] 1 1",
+		"[09:00:00.000] 1 2",
+		"[09:00:00.000] 1 3",
+		"[09:29:00.000] 3 1",
+		"[09:30:00.000] 4 1",
+		"[09:40:00.000] 10 1",
+		"[09:40:00.000] 12 1",
+		"[09:50:00.000] 10 2",
+		"[09:50:00.000] 12 2",
+	}, "\n")
+	conf := Config{
+		Format: Relay,
+		Laps:   1,
+		Start:  justTime(at(9, 30, 0, 0)),
+		Teams:  []Team{{Name: "Norway", Members: []int{1, 2}}},
+	}
+
+	lines := processLines(t, conf, NewReaderSource("input", strings.NewReader(input)))
+
+	for _, want := range []string{
+		"[09:00:00.000] The competitor(3) is disqualified: not entered (not in any team)",
+		"[09:40:00.000] The competitor(1) has finished",
+		"[09:40:00.000] The competitor(1) tagged over",
+		"[09:40:00.000] The competitor(2) started the leg after competitor(1)",
+		"[09:50:00.000] The competitor(2) has finished",
+		"error: " + ErrWrongEventsSequence.Error(),
+	} {
+		if !slices.Contains(lines, want) {
+			t.Errorf("missing %q in %q", want, lines)
+		}
+	}
+	// The first member has tagged over, so only the last one may be disqualified.
+	for _, line := range lines {
+		if strings.HasPrefix(line, "[09:50:00.000] The competitor(1) is disqualified") {
+			t.Errorf("relayed competitor is disqualified: %q", line)
+		}
+	}
+}
+
+func TestProcessorSpareRounds(t *testing.T) {
+	start := []string{
+		"[09:00:00.000] 1 1",
+		"[09:00:01.000] 2 1 09:30:00.000",
+		"[09:29:00.000] 3 1",
+		"[09:30:00.000] 4 1",
+		"[09:35:00.000] 5 1 1",
+	}
+	tests := []struct {
+		name        string
+		spareRounds int
+		shooting    []string
+		want        []string
+	}{
+		{
+			name:        "spares hit standing targets",
+			spareRounds: 2,
+			shooting: []string{
+				"[09:35:10.000] 6 1 1",
+				"[09:35:20.000] 13 1",
+				"[09:35:25.000] 6 1 2",
+				"[09:35:30.000] 13 1",
+				"[09:35:35.000] 13 1",
+			},
+			want: []string{
+				"[09:35:10.000] The target(1) has been hit by competitor(1)",
+				"[09:35:20.000] The competitor(1) loaded a spare round",
+				"[09:35:25.000] The target(2) has been hit by competitor(1)",
+				"[09:35:30.000] The competitor(1) loaded a spare round",
+				"error: " + ErrWrongEventsSequence.Error() + ": all 2 spare rounds are used",
+			},
+		},
+		{
+			name:        "all targets are hit",
+			spareRounds: 2,
+			shooting: []string{
+				"[09:35:10.000] 6 1 1",
+				"[09:35:15.000] 6 1 2",
+				"[09:35:20.000] 6 1 3",
+				"[09:35:25.000] 13 1",
+			},
+			want: []string{
+				"[09:35:10.000] The target(1) has been hit by competitor(1)",
+				"[09:35:15.000] The target(2) has been hit by competitor(1)",
+				"[09:35:20.000] The target(3) has been hit by competitor(1)",
+				"error: " + ErrWrongEventsSequence.Error() + ": all targets are hit",
+			},
+		},
+		{
+			name:     "spares are not allowed",
+			shooting: []string{"[09:35:10.000] 13 1"},
+			want:     []string{"error: " + ErrWrongEventsSequence.Error() + ": spare rounds are not allowed"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conf := Config{
+				Laps:          1,
+				FiringLines:   1,
+				ShotsPerRange: oneOrMany[int]{3},
+				SpareRounds:   tt.spareRounds,
+				StartDelta:    duration(30 * time.Second),
+			}
+			input := strings.Join(append(slices.Clone(start), tt.shooting...), "\n")
+
+			lines := processLines(t, conf, NewReaderSource("input", strings.NewReader(input)))
+
+			if len(lines) < len(start) {
+				t.Fatalf("logged %q", lines)
+			}
+			expectLines(t, lines[len(start):], tt.want)
+		})
+	}
+}
+
+func TestProcessorPenaltyLoopsInSeveralPasses(t *testing.T) {
+	start := []string{
+		"[09:05:59.867] 1 1",
+		"[09:15:00.841] 2 1 09:30:00.000",
+		"[09:29:45.734] 3 1",
+		"[09:30:01.005] 4 1",
+		"[09:49:31.659] 5 1 1",
+		"[09:49:33.123] 6 1 1",
+		"[09:49:38.339] 7 1",
+	}
+	// 4 loops of 50 m are owed, every 15 seconds in the penalty area are 2 loops at 7 m/s.
+	tests := []struct {
+		name    string
+		penalty []string
+		skipped int
+	}{
+		{
+			name: "one pass",
+			penalty: []string{
+				"[09:49:40.000] 8 1",
+				"[09:50:10.000] 9 1",
+			},
+		},
+		{
+			name: "two passes",
+			penalty: []string{
+				"[09:49:40.000] 8 1",
+				"[09:49:55.000] 9 1",
+				"[09:50:00.000] 8 1",
+				"[09:50:15.000] 9 1",
+			},
+		},
+		{
+			name: "loops left",
+			penalty: []string{
+				"[09:49:40.000] 8 1",
+				"[09:49:55.000] 9 1",
+			},
+			skipped: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input := strings.Join(append(append(slices.Clone(start), tt.penalty...), "[09:59:03.872] 10 1"), "\n")
+			conf := Config{
+				Laps:        2,
+				LapLen:      oneOrMany[float64]{3651},
+				PenaltyLen:  50,
+				FiringLines: 1,
+				StartDelta:  duration(30 * time.Second),
+			}
+
+			log := &recordingLogger{}
+			p := NewProcessor(conf, NewReaderSource("input", strings.NewReader(input)))
+			p.SetLogger(log)
+			p.Start()
+
+			for _, err := range log.errs {
+				t.Errorf("unexpected error: %v", err)
+			}
+			skipped := 0
+			for _, e := range log.events {
+				if e.Type == SkipPenaltyLoops {
+					skipped += e.ExtraParams[0].(int)
+				}
+			}
+			if skipped != tt.skipped {
+				t.Errorf("got %d skipped loops, want %d", skipped, tt.skipped)
+			}
+		})
+	}
+}
+
+func TestProcessorSkippedLoopsSanction(t *testing.T) {
+	input := strings.Join([]string{
+		"[09:05:59.867] 1 1",
+		"[09:15:00.841] 2 1 09:30:00.000",
+		"[09:29:45.734] 3 1",
+		"[09:30:01.005] 4 1",
+		"[09:49:31.659] 5 1 1",
+		"[09:49:33.123] 6 1 1",
+		"[09:49:34.650] 6 1 2",
+		"[09:49:35.937] 6 1 4",
+		"[09:49:38.339] 7 1",
+		"[09:49:40.000] 8 1",
+		"[09:49:45.000] 9 1",
+		"[09:59:03.872] 10 1",
+	}, "\n")
+	conf := Config{
+		Laps:            2,
+		LapLen:          oneOrMany[float64]{3651},
+		PenaltyLen:      50,
+		PenaltySanction: DisqualifySanction,
+		FiringLines:     1,
+		StartDelta:      duration(30 * time.Second),
+	}
+
+	// 5 seconds are not enough for a loop even at 7 m/s.
+	lines := processLines(t, conf, NewReaderSource("input", strings.NewReader(input)))
+	if want := "[09:59:03.872] The competitor(1) is disqualified: penalty loops skipped (2 loops)"; !slices.Contains(lines, want) {
+		t.Errorf("missing %q in %q", want, lines)
+	}
+
+	conf.PenaltySanction = TimeSanction
+	conf.MaxPenaltySpeed = 20
+	lines = processLines(t, conf, NewReaderSource("input", strings.NewReader(input)))
+	for _, line := range lines {
+		if strings.Contains(line, "skipped") {
+			t.Errorf("loops skied at 20 m/s are sanctioned: %q", line)
+		}
+	}
+}
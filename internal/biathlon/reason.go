@@ -0,0 +1,68 @@
+package biathlon
+
+import "strings"
+
+// ReasonCode tells why competitor is disqualified.
+type ReasonCode string
+
+const (
+	LateStart      ReasonCode = "LateStart"
+	FalseStart     ReasonCode = "FalseStart"
+	NotEntered     ReasonCode = "NotEntered"
+	DidNotStart    ReasonCode = "DidNotStart"
+	DidNotFinish   ReasonCode = "DidNotFinish"
+	RangeRevisited ReasonCode = "RangeRevisited"
+	RangesMissed   ReasonCode = "RangesMissed"
+	WrongRange     ReasonCode = "WrongRange"
+	PenaltySkipped ReasonCode = "PenaltySkipped"
+	JuryDecision   ReasonCode = "JuryDecision"
+)
+
+var reasonDescriptions = map[ReasonCode]string{
+	LateStart:      "late start",
+	FalseStart:     "false start",
+	NotEntered:     "not entered",
+	DidNotStart:    "not started",
+	DidNotFinish:   "not finished",
+	RangeRevisited: "firing range revisited",
+	RangesMissed:   "firing range missed",
+	WrongRange:     "out of range schedule",
+	PenaltySkipped: "penalty loops skipped",
+	JuryDecision:   "jury decision",
+}
+
+// Reason is the payload of Disqualify event: reason code
+// and optional details. Incoming Disqualify event without
+// reason is a jury decision.
+type Reason struct {
+	Code   ReasonCode
+	Detail string
+}
+
+func (c ReasonCode) valid() bool {
+	_, ok := reasonDescriptions[c]
+	return ok
+}
+
+// parseReason parses "Code details..." text.
+func parseReason(s string) (Reason, bool) {
+	code, detail, _ := strings.Cut(s, " ")
+	r := Reason{Code: ReasonCode(code), Detail: strings.TrimSpace(detail)}
+	return r, r.Code.valid()
+}
+
+// String returns reason in the form parseReason accepts.
+func (r Reason) String() string {
+	if r.Detail == "" {
+		return string(r.Code)
+	}
+	return string(r.Code) + " " + r.Detail
+}
+
+// Describe returns human-readable reason for logs.
+func (r Reason) Describe() string {
+	if r.Detail == "" {
+		return reasonDescriptions[r.Code]
+	}
+	return reasonDescriptions[r.Code] + " (" + r.Detail + ")"
+}
@@ -0,0 +1,136 @@
+package biathlon
+
+import (
+	"container/heap"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var ErrLateEvent = errors.New("event arrived after watermark")
+
+// ReorderSource holds events of the wrapped source for lateness window
+// and releases them in timestamp order. Watermark is the latest seen
+// timestamp minus lateness: everything before it is released, so events
+// older than watermark can't be put in order anymore and are reported as late.
+type ReorderSource struct {
+	wrapper
+	lateness time.Duration
+
+	buffer    reorderHeap
+	watermark time.Time
+	started   bool
+	seq       int
+}
+
+func NewReorderSource(source EventSource, lateness time.Duration) *ReorderSource {
+	return &ReorderSource{
+		wrapper:  newWrapper(source),
+		lateness: lateness,
+	}
+}
+
+func (r *ReorderSource) Start() {
+	r.pipe(r.hold, r.holdError, r.flush)
+}
+
+// hold buffers e and releases events before watermark.
+// It returns false if source is stopped.
+func (r *ReorderSource) hold(e Event) bool {
+	if r.started && e.TimeStamp.Before(r.watermark) {
+		err := fmt.Errorf(
+			"%w: event %d of competitor(%d) at %s is older than %s",
+			ErrLateEvent,
+			e.Type,
+			e.CompetitorID,
+			formatEventTime(e.TimeStamp),
+			formatEventTime(r.watermark),
+		)
+		return r.fail(&EventError{Event: e, Err: err})
+	}
+
+	r.push(reorderItem{event: e})
+
+	if mark := e.TimeStamp.Add(-r.lateness); !r.started || mark.After(r.watermark) {
+		r.watermark = mark
+		r.started = true
+	}
+
+	return r.release()
+}
+
+// holdError buffers err after events received before it,
+// so that it isn't reported ahead of them.
+func (r *ReorderSource) holdError(err error) bool {
+	if !r.started {
+		return r.fail(err)
+	}
+
+	latest := Event{TimeStamp: r.watermark.Add(r.lateness)}
+	r.push(reorderItem{event: latest, err: err})
+	return r.release()
+}
+
+func (r *ReorderSource) push(item reorderItem) {
+	item.seq = r.seq
+	r.seq++
+	heap.Push(&r.buffer, item)
+}
+
+// release passes held items up to watermark.
+func (r *ReorderSource) release() bool {
+	for r.buffer.Len() > 0 && !r.buffer[0].event.TimeStamp.After(r.watermark) {
+		if !r.pass(heap.Pop(&r.buffer).(reorderItem)) {
+			return false
+		}
+	}
+	return true
+}
+
+// flush releases the rest of items when source is exhausted.
+func (r *ReorderSource) flush() {
+	for r.buffer.Len() > 0 {
+		if !r.pass(heap.Pop(&r.buffer).(reorderItem)) {
+			return
+		}
+	}
+}
+
+func (r *ReorderSource) pass(item reorderItem) bool {
+	if item.err != nil {
+		return r.fail(item.err)
+	}
+	return r.send(item.event)
+}
+
+// reorderItem is a held event or an error placed at the latest time seen
+// before it. Arrival order is kept to release items with equal timestamps stably.
+type reorderItem struct {
+	event Event
+	err   error
+	seq   int
+}
+
+type reorderHeap []reorderItem
+
+func (h reorderHeap) Len() int { return len(h) }
+
+func (h reorderHeap) Less(i, j int) bool {
+	a, b := h[i], h[j]
+	if !a.event.TimeStamp.Equal(b.event.TimeStamp) {
+		return a.event.TimeStamp.Before(b.event.TimeStamp)
+	}
+	return a.seq < b.seq
+}
+
+func (h reorderHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+
+func (h *reorderHeap) Push(x any) { *h = append(*h, x.(reorderItem)) }
+
+func (h *reorderHeap) Pop() any {
+	old := *h
+	n := len(old)
+	x := old[n-1]
+	*h = old[:n-1]
+	return x
+}
@@ -0,0 +1,95 @@
+package biathlon
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReorderSource(t *testing.T) {
+	source := NewSliceSource("slice", []Event{
+		{TimeStamp: at(9, 0, 2, 0), CompetitorID: 2},
+		{TimeStamp: at(9, 0, 1, 0), CompetitorID: 1},
+		{TimeStamp: at(9, 0, 3, 0), CompetitorID: 3},
+		{TimeStamp: at(9, 0, 10, 0), CompetitorID: 4},
+		// Watermark is 09:00:05 by now.
+		{TimeStamp: at(9, 0, 4, 0), CompetitorID: 5},
+		{TimeStamp: at(9, 0, 10, 0), CompetitorID: 6},
+	})
+
+	events, errs := collect(NewReorderSource(source, 5*time.Second))
+
+	var ids []int
+	for _, e := range events {
+		ids = append(ids, e.CompetitorID)
+	}
+	if want := []int{1, 2, 3, 4, 6}; !slices.Equal(ids, want) {
+		t.Errorf("released competitors %v, want %v", ids, want)
+	}
+
+	var evErr *EventError
+	if len(errs) != 1 || !errors.Is(errs[0], ErrLateEvent) || !errors.As(errs[0], &evErr) {
+		t.Fatalf("errs = %v, want one late event error", errs)
+	}
+	if evErr.Event.CompetitorID != 5 {
+		t.Errorf("late event = %+v, want competitor 5", evErr.Event)
+	}
+}
+
+func TestReorderSourceFlushesWhenInputStops(t *testing.T) {
+	input := newPushSource()
+	reorder := NewReorderSource(input, time.Hour)
+	go reorder.Start()
+
+	// Consumer isn't blocked: everything is held within lateness window.
+	input.push(t,
+		Event{TimeStamp: at(9, 0, 3, 0), CompetitorID: 3},
+		Event{TimeStamp: at(9, 0, 1, 0), CompetitorID: 1},
+		Event{TimeStamp: at(9, 0, 2, 0), CompetitorID: 2},
+	)
+	input.Stop()
+
+	for want := 1; want <= 3; want++ {
+		if e := nextEvent(t, reorder.Events()); e.CompetitorID != want {
+			t.Errorf("released %+v, want competitor %d", e, want)
+		}
+	}
+	expectClosed(t, reorder.Events())
+}
+
+func TestReorderSourceStop(t *testing.T) {
+	input := newPushSource()
+	reorder := NewReorderSource(input, time.Hour)
+	go reorder.Start()
+
+	input.push(t, Event{TimeStamp: at(9, 0, 1, 0), CompetitorID: 1})
+	reorder.Stop()
+
+	// Stopping the whole pipeline drops held events.
+	expectClosed(t, reorder.Events())
+	expectClosed(t, input.Events())
+}
+
+func TestReorderSourceHoldsErrors(t *testing.T) {
+	input := strings.Join([]string{
+		"bad line 1",
+		"[09:00:02.000] 1 2",
+		"[09:00:01.000] 1 1",
+		"bad line 2",
+		"[09:00:01.500] 1 3",
+		"[09:00:10.000] 1 4",
+	}, "\n")
+	source := NewReorderSource(NewReaderSource("input", strings.NewReader(input)), 5*time.Second)
+
+	// Error is held after events read before it.
+	expectLines(t, processLines(t, Config{}, source), []string{
+		"error: input: ",
+		"[09:00:01.000] The competitor(1) registered",
+		"[09:00:01.500] The competitor(3) registered",
+		"[09:00:02.000] The competitor(2) registered",
+		"error: input: ",
+		"[09:00:10.000] The competitor(4) registered",
+	})
+}
@@ -0,0 +1,181 @@
+package biathlon
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+var ErrSeekBackwards = errors.New("replay can't seek backwards")
+
+// ReplaySource passes events of the wrapped source in wall-clock time:
+// it sleeps according to the gaps between their timestamps divided
+// by speed. Replay can be paused, resumed, sped up and moved forward
+// while it's running.
+type ReplaySource struct {
+	wrapper
+
+	mu     sync.Mutex
+	speed  float64
+	paused bool
+	// Replay position is anchorEvent at anchorWall moment
+	// and it moves forward with speed unless replay is paused.
+	anchored    bool
+	anchorWall  time.Time
+	anchorEvent time.Time
+	seeking     bool
+	seekTo      time.Time
+
+	// wake interrupts waiting for the next event when controls change.
+	wake chan struct{}
+}
+
+func NewReplaySource(source EventSource, speed float64) *ReplaySource {
+	return &ReplaySource{
+		wrapper: newWrapper(source),
+		speed:   speed,
+		wake:    make(chan struct{}, 1),
+	}
+}
+
+func (r *ReplaySource) Pause() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !r.paused {
+		r.reanchor(r.position(time.Now()))
+		r.paused = true
+	}
+	r.notify()
+}
+
+func (r *ReplaySource) Resume() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.paused {
+		r.reanchor(r.anchorEvent)
+		r.paused = false
+	}
+	r.notify()
+}
+
+// SetSpeed changes how many times replay is faster than the race.
+func (r *ReplaySource) SetSpeed(speed float64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.reanchor(r.position(time.Now()))
+	r.speed = speed
+	r.notify()
+}
+
+// Seek passes events before t without waiting. Time without date
+// is taken on the date of the current replay position, or on the date
+// of the first event if replay hasn't started yet.
+func (r *ReplaySource) Seek(t time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.anchored {
+		pos := r.position(time.Now())
+		if !hasDate(t) {
+			t = onDate(pos, t)
+		}
+		if t.Before(pos) {
+			return ErrSeekBackwards
+		}
+	}
+
+	r.seeking = true
+	r.seekTo = t
+	r.notify()
+	return nil
+}
+
+// Position returns time of the race being replayed now.
+// It's zero time until the first event is read.
+func (r *ReplaySource) Position() time.Time {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.position(time.Now())
+}
+
+func (r *ReplaySource) position(now time.Time) time.Time {
+	if !r.anchored || r.paused {
+		return r.anchorEvent
+	}
+	elapsed := float64(now.Sub(r.anchorWall)) * r.speed
+	return r.anchorEvent.Add(time.Duration(elapsed))
+}
+
+func (r *ReplaySource) reanchor(pos time.Time) {
+	r.anchorWall = time.Now()
+	r.anchorEvent = pos
+}
+
+func (r *ReplaySource) notify() {
+	select {
+	case r.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (r *ReplaySource) Start() {
+	r.pipe(func(e Event) bool {
+		return r.wait(e) && r.send(e)
+	}, nil, nil)
+}
+
+// wait blocks until it's time to pass e. It returns false if replay is stopped.
+func (r *ReplaySource) wait(e Event) bool {
+	for {
+		r.mu.Lock()
+		if !r.anchored {
+			r.reanchor(e.TimeStamp)
+			r.anchored = true
+			if r.seeking && !hasDate(r.seekTo) {
+				r.seekTo = onDate(e.TimeStamp, r.seekTo)
+			}
+		}
+
+		if r.seeking {
+			if e.TimeStamp.Before(r.seekTo) {
+				r.mu.Unlock()
+				return true
+			}
+			r.seeking = false
+			r.reanchor(e.TimeStamp)
+		}
+
+		if r.paused {
+			r.mu.Unlock()
+			select {
+			case <-r.wake:
+				continue
+			case <-r.done:
+				return false
+			}
+		}
+
+		delay := time.Duration(float64(e.TimeStamp.Sub(r.anchorEvent)) / r.speed)
+		target := r.anchorWall.Add(delay)
+		r.mu.Unlock()
+
+		d := time.Until(target)
+		if d <= 0 {
+			return true
+		}
+
+		timer := time.NewTimer(d)
+		select {
+		case <-timer.C:
+			return true
+		case <-r.wake:
+			timer.Stop()
+		case <-r.done:
+			timer.Stop()
+			return false
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package biathlon
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func replayEvents(times ...time.Time) []Event {
+	events := make([]Event, len(times))
+	for i, ts := range times {
+		events[i] = Event{TimeStamp: ts, Type: Register, CompetitorID: i + 1}
+	}
+	return events
+}
+
+func TestReplaySourcePacesEvents(t *testing.T) {
+	input := replayEvents(at(9, 0, 0, 0), at(9, 0, 1, 0), at(9, 0, 2, 0))
+	replay := NewReplaySource(NewSliceSource("input", input), 100)
+
+	begin := time.Now()
+	events, errs := collect(replay)
+	elapsed := time.Since(begin)
+
+	if len(events) != len(input) || len(errs) != 0 {
+		t.Fatalf("replayed %v, errors %v", events, errs)
+	}
+	// Two seconds of the race take 20ms being replayed 100 times faster.
+	if elapsed < 20*time.Millisecond || elapsed > time.Second {
+		t.Errorf("replay took %v, want about 20ms", elapsed)
+	}
+}
+
+func TestReplaySourceSeek(t *testing.T) {
+	day := func(h, m int) time.Time {
+		return time.Date(2025, 2, 1, h, m, 0, 0, time.UTC)
+	}
+	input := replayEvents(day(9, 0), day(9, 30), day(10, 0))
+	replay := NewReplaySource(NewSliceSource("input", input), 1)
+
+	// Time without date is taken on the date of the first event.
+	if err := replay.Seek(at(10, 0, 0, 0)); err != nil {
+		t.Fatal(err)
+	}
+	go replay.Start()
+
+	for range input {
+		nextEvent(t, replay.Events())
+	}
+	expectClosed(t, replay.Events())
+
+	if err := replay.Seek(at(9, 0, 0, 0)); !errors.Is(err, ErrSeekBackwards) {
+		t.Errorf("Seek() backwards = %v, want %v", err, ErrSeekBackwards)
+	}
+}
+
+func TestReplaySourcePauseAndStop(t *testing.T) {
+	input := replayEvents(at(9, 0, 0, 0), at(9, 0, 0, 10))
+	replay := NewReplaySource(NewSliceSource("input", input), 1)
+	go replay.Start()
+
+	nextEvent(t, replay.Events())
+	replay.Pause()
+	select {
+	case e := <-replay.Events():
+		t.Fatalf("event %+v passed while replay is paused", e)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	// Stopped replay doesn't wait for resume.
+	replay.Stop()
+	expectClosed(t, replay.Events())
+}
@@ -0,0 +1,301 @@
+package biathlon
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+)
+
+// EventSource is a stream of incoming events consumed by Processor.
+type EventSource interface {
+	// Name identifies source in logs and error messages.
+	Name() string
+	// Start produces events until source is exhausted or stopped.
+	// Events and Errors channels are closed when Start returns.
+	Start()
+	// Stop ends producing events. It's safe to call Stop several times.
+	Stop()
+	Events() <-chan Event
+	// Errors reports events that can't be parsed and failures of the source.
+	Errors() <-chan error
+}
+
+// EventError is an error caused by a particular event.
+type EventError struct {
+	Event Event
+	Err   error
+}
+
+func (e *EventError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *EventError) Unwrap() error {
+	return e.Err
+}
+
+// withLine attaches number of line in the source to the parsing error.
+func withLine(err error, line int) error {
+	var parseErr *ParseError
+	if errors.As(err, &parseErr) {
+		parseErr.Line = line
+		return err
+	}
+	return fmt.Errorf("line %d: %w", line, err)
+}
+
+// stream implements channels handling shared by all event sources.
+type stream struct {
+	events chan Event
+	errs   chan error
+
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+func newStream() stream {
+	return stream{
+		events: make(chan Event),
+		errs:   make(chan error),
+		done:   make(chan struct{}),
+	}
+}
+
+func (s *stream) Events() <-chan Event {
+	return s.events
+}
+
+func (s *stream) Errors() <-chan error {
+	return s.errs
+}
+
+func (s *stream) Stop() {
+	s.stopOnce.Do(func() {
+		close(s.done)
+	})
+}
+
+// send returns false if stream has been stopped.
+func (s *stream) send(e Event) bool {
+	select {
+	case s.events <- e:
+		return true
+	case <-s.done:
+		return false
+	}
+}
+
+// fail returns false if stream has been stopped.
+func (s *stream) fail(err error) bool {
+	select {
+	case s.errs <- err:
+		return true
+	case <-s.done:
+		return false
+	}
+}
+
+func (s *stream) stopped() bool {
+	select {
+	case <-s.done:
+		return true
+	default:
+		return false
+	}
+}
+
+func (s *stream) close() {
+	close(s.events)
+	close(s.errs)
+}
+
+// drain reads events and errors of the stopped source until it's closed,
+// so that the source could finish.
+func drain(source EventSource) {
+	events, errs := source.Events(), source.Errors()
+	for events != nil || errs != nil {
+		select {
+		case _, ok := <-events:
+			if !ok {
+				events = nil
+			}
+		case _, ok := <-errs:
+			if !ok {
+				errs = nil
+			}
+		}
+	}
+}
+
+// wrapper is a stream passing events of the wrapped source through a stage.
+type wrapper struct {
+	stream
+	source EventSource
+}
+
+func newWrapper(source EventSource) wrapper {
+	return wrapper{
+		stream: newStream(),
+		source: source,
+	}
+}
+
+func (w *wrapper) Source() EventSource {
+	return w.source
+}
+
+func (w *wrapper) Name() string {
+	return w.source.Name()
+}
+
+// Stop stops the wrapper together with the wrapped source, so that
+// events and errors held by the stage are dropped. Stop the wrapped
+// source alone to let the stage pass them before it finishes.
+func (w *wrapper) Stop() {
+	w.stream.Stop()
+	w.source.Stop()
+}
+
+// pipe starts the wrapped source and passes its events to stage and its
+// errors to hold in the order they come, until the source is exhausted
+// or the wrapper is stopped. Errors are forwarded as they are if hold
+// is nil. Then finish (if set) is called right before closing channels,
+// unless the wrapper is stopped. Stages return false when the wrapper
+// is stopped.
+func (w *wrapper) pipe(stage func(Event) bool, hold func(error) bool, finish func()) {
+	if hold == nil {
+		hold = w.fail
+	}
+
+	go w.source.Start()
+	defer w.close()
+
+	events, errs := w.source.Events(), w.source.Errors()
+	for events != nil || errs != nil {
+		var ok bool
+		select {
+		case e, open := <-events:
+			if !open {
+				events = nil
+				continue
+			}
+			ok = stage(e)
+		case err, open := <-errs:
+			if !open {
+				errs = nil
+				continue
+			}
+			ok = hold(err)
+		}
+
+		if !ok {
+			// Stopped source is drained, so that it could finish.
+			drain(w.source)
+			return
+		}
+	}
+
+	if finish != nil && !w.stopped() {
+		finish()
+	}
+}
+
+// ReaderSource reads events line by line from io.Reader until EOF
+// or EndOfRaceMarker.
+type ReaderSource struct {
+	stream
+	name   string
+	reader io.Reader
+	parser LineParser
+}
+
+func NewReaderSource(name string, r io.Reader) *ReaderSource {
+	return &ReaderSource{
+		stream: newStream(),
+		name:   name,
+		reader: r,
+		parser: ParseEvent,
+	}
+}
+
+// NewStdinSource reads events from standard input.
+func NewStdinSource() *ReaderSource {
+	return NewReaderSource("stdin", os.Stdin)
+}
+
+func (r *ReaderSource) Name() string {
+	return r.name
+}
+
+// SetParser sets format of the lines. ParseEvent is used by default.
+func (r *ReaderSource) SetParser(parser LineParser) {
+	r.parser = parser
+}
+
+func (r *ReaderSource) Start() {
+	defer r.close()
+
+	scanner := bufio.NewScanner(r.reader)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := scanner.Text()
+		if strings.TrimSpace(line) == EndOfRaceMarker {
+			return
+		}
+
+		event, err := r.parser(line)
+		if errors.Is(err, ErrEmptyLine) {
+			continue
+		}
+		if err != nil {
+			if !r.fail(fmt.Errorf("%s: %w", r.name, withLine(err, lineNo))) {
+				return
+			}
+			continue
+		}
+
+		event.Source = r.name
+		if !r.send(event) {
+			return
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		r.fail(fmt.Errorf("%s: %w", r.name, err))
+	}
+}
+
+// SliceSource produces events stored in memory.
+type SliceSource struct {
+	stream
+	name string
+	list []Event
+}
+
+func NewSliceSource(name string, events []Event) *SliceSource {
+	return &SliceSource{
+		stream: newStream(),
+		name:   name,
+		list:   events,
+	}
+}
+
+func (s *SliceSource) Name() string {
+	return s.name
+}
+
+func (s *SliceSource) Start() {
+	defer s.close()
+
+	for _, e := range s.list {
+		if e.Source == "" {
+			e.Source = s.name
+		}
+		if !s.send(e) {
+			return
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package biathlon
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// at returns time of day without date, like ParseEvent does.
+func at(h, m, s, ms int) time.Time {
+	return time.Date(0, 1, 1, h, m, s, ms*int(time.Millisecond), time.UTC)
+}
+
+// collect runs source and reads all its events and errors.
+func collect(source EventSource) ([]Event, []error) {
+	go source.Start()
+
+	var events []Event
+	var errs []error
+	eventsCh, errsCh := source.Events(), source.Errors()
+	for eventsCh != nil || errsCh != nil {
+		select {
+		case e, ok := <-eventsCh:
+			if !ok {
+				eventsCh = nil
+				continue
+			}
+			events = append(events, e)
+		case err, ok := <-errsCh:
+			if !ok {
+				errsCh = nil
+				continue
+			}
+			errs = append(errs, err)
+		}
+	}
+	return events, errs
+}
+
+func TestReaderSource(t *testing.T) {
+	input := strings.Join([]string{
+		"[09:05:59.867] 1 1",
+		"[09:05:59.900] one 2",
+		"[09:06:00.000] 1 3",
+		EndOfRaceMarker,
+		"[09:06:01.000] 1 4",
+	}, "\n")
+
+	events, errs := collect(NewReaderSource("input", strings.NewReader(input)))
+
+	if len(events) != 2 || events[0].CompetitorID != 1 || events[1].CompetitorID != 3 {
+		t.Errorf("events = %+v, want competitors 1 and 3", events)
+	}
+	if len(errs) != 1 || !strings.HasPrefix(errs[0].Error(), "input: ") {
+		t.Errorf("errs = %v, want one error prefixed with source name", errs)
+	}
+}
+
+func TestSliceSourceStop(t *testing.T) {
+	source := NewSliceSource("slice", []Event{{Type: Register, CompetitorID: 1}, {Type: Register, CompetitorID: 2}})
+	go source.Start()
+
+	if e := nextEvent(t, source.Events()); e.CompetitorID != 1 {
+		t.Errorf("first event = %+v", e)
+	}
+	source.Stop()
+	source.Stop()
+	expectClosed(t, source.Events())
+}
+
+// pushSource produces events pushed by test until it's stopped,
+// like a file followed or a TCP listener does.
+type pushSource struct {
+	stream
+	in   chan Event
+	sent chan struct{}
+}
+
+func newPushSource() *pushSource {
+	return &pushSource{
+		stream: newStream(),
+		in:     make(chan Event),
+		sent:   make(chan struct{}),
+	}
+}
+
+func (s *pushSource) Name() string {
+	return "push"
+}
+
+func (s *pushSource) Start() {
+	defer s.close()
+
+	for {
+		select {
+		case e := <-s.in:
+			if !s.send(e) {
+				return
+			}
+			s.sent <- struct{}{}
+		case <-s.done:
+			return
+		}
+	}
+}
+
+// push returns when e is received by consumer of the source.
+func (s *pushSource) push(t *testing.T, events ...Event) {
+	t.Helper()
+
+	for _, e := range events {
+		select {
+		case s.in <- e:
+		case <-time.After(time.Second):
+			t.Fatal("event isn't consumed in time")
+		}
+		<-s.sent
+	}
+}
@@ -0,0 +1,165 @@
+package biathlon
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"strings"
+	"sync"
+)
+
+// TCPListener accepts events from timing hardware over TCP.
+// Each line sent by client is parsed and answered
+// with "OK" or with "ERR <reason>" if it can't be parsed.
+type TCPListener struct {
+	stream
+	listener net.Listener
+
+	mu    sync.Mutex
+	conns map[net.Conn]struct{}
+	wg    sync.WaitGroup
+
+	// closeOnce guards closing of listening socket and connections,
+	// stream.Stop has its own guard for the done channel.
+	closeOnce sync.Once
+
+	parser LineParser
+
+	log *log.Logger
+}
+
+func NewTCPListener(addr string) (*TCPListener, error) {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return &TCPListener{}, err
+	}
+
+	return &TCPListener{
+		stream:   newStream(),
+		listener: ln,
+		conns:    make(map[net.Conn]struct{}),
+		parser:   ParseEvent,
+		log:      log.New(os.Stdout, "TCP listener: ", log.Ltime),
+	}, nil
+}
+
+func (l *TCPListener) Addr() net.Addr {
+	return l.listener.Addr()
+}
+
+func (l *TCPListener) Name() string {
+	return "tcp:" + l.listener.Addr().String()
+}
+
+func (l *TCPListener) SetLogger(log *log.Logger) {
+	l.log = log
+}
+
+// SetParser sets format of the received lines. ParseEvent is used by default.
+func (l *TCPListener) SetParser(parser LineParser) {
+	l.parser = parser
+}
+
+// Stop closes listening socket and all client connections.
+// It's safe to call Stop several times.
+func (l *TCPListener) Stop() {
+	l.closeOnce.Do(func() {
+		l.stream.Stop()
+		if err := l.listener.Close(); err != nil {
+			l.log.Println(err)
+		}
+
+		l.mu.Lock()
+		for conn := range l.conns {
+			conn.Close()
+		}
+		l.mu.Unlock()
+	})
+}
+
+// Start accepts connections until Stop is called
+// or some client sends EndOfRaceMarker.
+func (l *TCPListener) Start() {
+	defer l.close()
+
+	for {
+		conn, err := l.listener.Accept()
+		if err != nil {
+			if !l.stopped() {
+				l.fail(fmt.Errorf("%s: %w", l.Name(), err))
+				l.Stop()
+			}
+			break
+		}
+
+		l.mu.Lock()
+		if l.stopped() {
+			l.mu.Unlock()
+			conn.Close()
+			break
+		}
+		l.conns[conn] = struct{}{}
+		l.mu.Unlock()
+
+		l.wg.Add(1)
+		go l.serve(conn)
+	}
+
+	l.wg.Wait()
+}
+
+func (l *TCPListener) serve(conn net.Conn) {
+	defer l.wg.Done()
+	defer func() {
+		l.mu.Lock()
+		delete(l.conns, conn)
+		l.mu.Unlock()
+		conn.Close()
+	}()
+
+	l.log.Printf("%s connected\n", conn.RemoteAddr())
+
+	scanner := bufio.NewScanner(conn)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := scanner.Text()
+		if strings.TrimSpace(line) == EndOfRaceMarker {
+			l.reply(conn, "OK")
+			l.Stop()
+			return
+		}
+
+		event, err := l.parser(line)
+		if errors.Is(err, ErrEmptyLine) {
+			l.reply(conn, "OK")
+			continue
+		}
+		if err != nil {
+			err = withLine(err, lineNo)
+			l.reply(conn, fmt.Sprintf("ERR %v", err))
+			if !l.fail(fmt.Errorf("%s: %w", conn.RemoteAddr(), err)) {
+				return
+			}
+			continue
+		}
+
+		event.Source = l.Name()
+		if !l.send(event) {
+			return
+		}
+		l.reply(conn, "OK")
+	}
+
+	if err := scanner.Err(); err != nil && !l.stopped() {
+		l.log.Printf("%s: %v\n", conn.RemoteAddr(), err)
+	}
+	l.log.Printf("%s disconnected\n", conn.RemoteAddr())
+}
+
+func (l *TCPListener) reply(conn net.Conn, msg string) {
+	if _, err := fmt.Fprintln(conn, msg); err != nil {
+		l.log.Printf("%s: %v\n", conn.RemoteAddr(), err)
+	}
+}
@@ -0,0 +1,121 @@
+package biathlon
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestTCPListener(t *testing.T) *TCPListener {
+	t.Helper()
+
+	listener, err := NewTCPListener("127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	listener.SetLogger(log.New(io.Discard, "", 0))
+	t.Cleanup(listener.Stop)
+	return listener
+}
+
+type tcpClient struct {
+	conn    net.Conn
+	replies *bufio.Scanner
+}
+
+func dial(t *testing.T, listener *TCPListener) *tcpClient {
+	t.Helper()
+
+	conn, err := net.Dial("tcp", listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	conn.SetDeadline(time.Now().Add(time.Second))
+	return &tcpClient{conn: conn, replies: bufio.NewScanner(conn)}
+}
+
+func (c *tcpClient) send(t *testing.T, line string) {
+	t.Helper()
+
+	if _, err := fmt.Fprintln(c.conn, line); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func (c *tcpClient) reply(t *testing.T) string {
+	t.Helper()
+
+	if !c.replies.Scan() {
+		t.Fatalf("no reply: %v", c.replies.Err())
+	}
+	return c.replies.Text()
+}
+
+func TestTCPListener(t *testing.T) {
+	listener := newTestTCPListener(t)
+	go listener.Start()
+
+	client := dial(t, listener)
+	events := listener.Events()
+
+	client.send(t, "[09:05:59.867] 1 1")
+	if e := nextEvent(t, events); e.Type != Register || e.CompetitorID != 1 {
+		t.Errorf("event = %+v", e)
+	}
+	if reply := client.reply(t); reply != "OK" {
+		t.Errorf("reply = %q, want OK", reply)
+	}
+
+	client.send(t, "[09:05:59.867] one 1")
+	if reply := client.reply(t); !strings.HasPrefix(reply, "ERR ") {
+		t.Errorf("reply = %q, want ERR", reply)
+	}
+	select {
+	case err := <-listener.Errors():
+		if !strings.Contains(err.Error(), client.conn.LocalAddr().String()) {
+			t.Errorf("error %q doesn't mention client address", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no error reported")
+	}
+
+	// Several stations may send events at the same time.
+	other := dial(t, listener)
+	other.send(t, "[09:06:00.000] 1 2")
+	if e := nextEvent(t, events); e.CompetitorID != 2 {
+		t.Errorf("event from second client = %+v", e)
+	}
+	if reply := other.reply(t); reply != "OK" {
+		t.Errorf("reply = %q, want OK", reply)
+	}
+
+	client.send(t, EndOfRaceMarker)
+	if reply := client.reply(t); reply != "OK" {
+		t.Errorf("reply = %q, want OK", reply)
+	}
+	expectClosed(t, events)
+}
+
+func TestTCPListenerStop(t *testing.T) {
+	listener := newTestTCPListener(t)
+	go listener.Start()
+
+	client := dial(t, listener)
+	client.send(t, "[09:05:59.867] 1 1")
+	nextEvent(t, listener.Events())
+	client.reply(t)
+
+	listener.Stop()
+	expectClosed(t, listener.Events())
+
+	// Connections are closed by Stop as well.
+	if client.replies.Scan() {
+		t.Errorf("unexpected reply %q after Stop", client.replies.Text())
+	}
+}
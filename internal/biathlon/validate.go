@@ -0,0 +1,399 @@
+package biathlon
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+)
+
+var ErrInvalidConfig = errors.New("invalid config")
+
+// ConfigError describes a problem with a config field at JSON path.
+type ConfigError struct {
+	Path string
+	Msg  string
+}
+
+func (e *ConfigError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Path, e.Msg)
+}
+
+func (e *ConfigError) Unwrap() error {
+	return ErrInvalidConfig
+}
+
+func configErr(path, format string, args ...any) error {
+	return &ConfigError{Path: path, Msg: fmt.Sprintf(format, args...)}
+}
+
+// Validate checks all config fields and constraints between them.
+// Every problem found is reported in the joined error.
+func (c Config) Validate() error {
+	var errs []error
+
+	if c.Laps < 1 {
+		errs = append(errs, configErr("$.laps", "must be at least 1, got %d", c.Laps))
+	}
+	switch {
+	case len(c.LapLen) == 0:
+		errs = append(errs, configErr("$.lapLen", "is required"))
+	case len(c.LapLen) == 1:
+		if c.LapLen[0] <= 0 {
+			errs = append(errs, configErr("$.lapLen", "must be positive, got %g", c.LapLen[0]))
+		}
+	default:
+		if len(c.LapLen) != c.Laps {
+			errs = append(errs, configErr(
+				"$.lapLen",
+				"must have length of every lap, got %d lengths for %d laps",
+				len(c.LapLen),
+				c.Laps,
+			))
+		}
+		for i, l := range c.LapLen {
+			if l <= 0 {
+				errs = append(errs, configErr(fmt.Sprintf("$.lapLen[%d]", i), "must be positive, got %g", l))
+			}
+		}
+	}
+	switch c.RaceFormat() {
+	case Sprint, Pursuit, MassStart, Relay:
+		if c.PenaltyLen <= 0 {
+			errs = append(errs, configErr("$.penaltyLen", "must be positive, got %g", c.PenaltyLen))
+		}
+	case Individual:
+		if c.PenaltyLen < 0 {
+			errs = append(errs, configErr("$.penaltyLen", "must not be negative, got %g", c.PenaltyLen))
+		}
+	default:
+		errs = append(errs, configErr(
+			"$.format",
+			"must be %q, %q, %q, %q or %q, got %q",
+			Sprint,
+			Individual,
+			Pursuit,
+			MassStart,
+			Relay,
+			c.Format,
+		))
+	}
+
+	if c.FiringLines < 0 {
+		errs = append(errs, configErr("$.firingLines", "must not be negative, got %d", c.FiringLines))
+	}
+	if c.Laps >= 1 && c.FiringLines > c.Laps {
+		errs = append(errs, configErr(
+			"$.firingLines",
+			"must not exceed laps, got %d firing lines for %d laps",
+			c.FiringLines,
+			c.Laps,
+		))
+	}
+	switch {
+	case len(c.ShotsPerRange) == 1:
+		if c.ShotsPerRange[0] < 1 {
+			errs = append(errs, configErr("$.shotsPerRange", "must be at least 1, got %d", c.ShotsPerRange[0]))
+		}
+	case len(c.ShotsPerRange) > 1:
+		if len(c.ShotsPerRange) != c.FiringLines {
+			errs = append(errs, configErr(
+				"$.shotsPerRange",
+				"must have shots of every range, got %d numbers for %d firing lines",
+				len(c.ShotsPerRange),
+				c.FiringLines,
+			))
+		}
+		for i, shots := range c.ShotsPerRange {
+			if shots < 1 {
+				errs = append(errs, configErr(fmt.Sprintf("$.shotsPerRange[%d]", i), "must be at least 1, got %d", shots))
+			}
+		}
+	}
+	if c.MaxPenaltySpeed < 0 {
+		errs = append(errs, configErr("$.maxPenaltySpeed", "must not be negative, got %g", c.MaxPenaltySpeed))
+	}
+	switch c.PenaltySanction {
+	case "", TimeSanction, DisqualifySanction:
+	default:
+		errs = append(errs, configErr(
+			"$.penaltySanction",
+			"must be %q or %q, got %q",
+			TimeSanction,
+			DisqualifySanction,
+			c.PenaltySanction,
+		))
+	}
+	if c.SpareRounds < 0 {
+		errs = append(errs, configErr("$.spareRounds", "must not be negative, got %d", c.SpareRounds))
+	}
+	errs = append(errs, c.validateSchedule()...)
+	errs = append(errs, c.validateTeams()...)
+	if time.Time(c.Start).IsZero() {
+		errs = append(errs, configErr("$.start", "is required"))
+	}
+	if c.StartDelta <= 0 && c.RaceFormat().Drawn() {
+		errs = append(errs, configErr("$.startDelta", "must be positive"))
+	}
+
+	return errors.Join(errs...)
+}
+
+func (c Config) validateSchedule() []error {
+	if len(c.RangeSchedule) == 0 {
+		return nil
+	}
+
+	var errs []error
+	if len(c.RangeSchedule) != c.FiringLines {
+		errs = append(errs, configErr(
+			"$.rangeSchedule",
+			"must have a slot for every range, got %d slots for %d firing lines",
+			len(c.RangeSchedule),
+			c.FiringLines,
+		))
+	}
+
+	laps := make(map[int]struct{})
+	ranges := make(map[int]struct{})
+	for i, slot := range c.RangeSchedule {
+		path := fmt.Sprintf("$.rangeSchedule[%d]", i)
+
+		if slot.Lap < 1 || slot.Lap > c.Laps {
+			errs = append(errs, configErr(path+".lap", "must be within 1..%d, got %d", c.Laps, slot.Lap))
+		} else if _, ok := laps[slot.Lap]; ok {
+			errs = append(errs, configErr(path+".lap", "lap %d is already scheduled", slot.Lap))
+		}
+		laps[slot.Lap] = struct{}{}
+
+		if slot.Range < 1 || slot.Range > c.FiringLines {
+			errs = append(errs, configErr(path+".range", "must be within 1..%d, got %d", c.FiringLines, slot.Range))
+		} else if _, ok := ranges[slot.Range]; ok {
+			errs = append(errs, configErr(path+".range", "range %d is already scheduled", slot.Range))
+		}
+		ranges[slot.Range] = struct{}{}
+
+		if slot.Position != Prone && slot.Position != Standing {
+			errs = append(errs, configErr(
+				path+".position",
+				"must be %q or %q, got %q",
+				Prone,
+				Standing,
+				slot.Position,
+			))
+		}
+	}
+	return errs
+}
+
+func (c Config) validateTeams() []error {
+	if c.RaceFormat() != Relay {
+		if len(c.Teams) > 0 {
+			return []error{configErr("$.teams", "are used only in relay")}
+		}
+		return nil
+	}
+	if len(c.Teams) == 0 {
+		return []error{configErr("$.teams", "are required in relay")}
+	}
+
+	var errs []error
+	names := make(map[string]struct{})
+	members := make(map[int]string)
+	for i, team := range c.Teams {
+		path := fmt.Sprintf("$.teams[%d]", i)
+
+		if team.Name == "" {
+			errs = append(errs, configErr(path+".name", "is required"))
+		} else if _, ok := names[team.Name]; ok {
+			errs = append(errs, configErr(path+".name", "team %q already exists", team.Name))
+		}
+		names[team.Name] = struct{}{}
+
+		if len(team.Members) == 0 {
+			errs = append(errs, configErr(path+".members", "are required"))
+		} else if legs := len(c.Teams[0].Members); len(team.Members) != legs {
+			errs = append(errs, configErr(
+				path+".members",
+				"must have %d legs like the first team, got %d",
+				legs,
+				len(team.Members),
+			))
+		}
+		for j, id := range team.Members {
+			memberPath := fmt.Sprintf("%s.members[%d]", path, j)
+			if other, ok := members[id]; ok {
+				errs = append(errs, configErr(memberPath, "competitor %d is already in team %q", id, other))
+			}
+			members[id] = team.Name
+		}
+	}
+	return errs
+}
+
+// decodeConfig unmarshals JSON value at path into v field by field, so that
+// every value of a wrong type is reported with its path rather than only
+// the first one. Keys of objects which don't match any json tag of v
+// are reported too, so that typos in config are not silently ignored.
+func decodeConfig(path string, data []byte, v reflect.Value) []error {
+	if string(data) == "null" {
+		return nil
+	}
+	if u, ok := v.Addr().Interface().(json.Unmarshaler); ok {
+		if err := u.UnmarshalJSON(data); err != nil {
+			return []error{configErr(path, "%v", err)}
+		}
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		var raw map[string]json.RawMessage
+		if err := json.Unmarshal(data, &raw); err != nil {
+			return []error{configErr(path, "must be an object, got %s", jsonKind(data))}
+		}
+
+		keys := make([]string, 0, len(raw))
+		for key := range raw {
+			keys = append(keys, key)
+		}
+		slices.Sort(keys)
+
+		var errs []error
+		for _, key := range keys {
+			i := fieldIndex(v.Type(), key)
+			if i == -1 {
+				errs = append(errs, unknownKey(path+"."+key, key, jsonKeys(v.Type())))
+				continue
+			}
+			errs = append(errs, decodeConfig(path+"."+key, raw[key], v.Field(i))...)
+		}
+		return errs
+	case reflect.Slice:
+		var raw []json.RawMessage
+		if err := json.Unmarshal(data, &raw); err != nil {
+			return []error{configErr(path, "must be a list, got %s", jsonKind(data))}
+		}
+
+		var errs []error
+		v.Set(reflect.MakeSlice(v.Type(), len(raw), len(raw)))
+		for i, item := range raw {
+			errs = append(errs, decodeConfig(fmt.Sprintf("%s[%d]", path, i), item, v.Index(i))...)
+		}
+		return errs
+	default:
+		return decodeValue(path, data, v)
+	}
+}
+
+// decodeValue unmarshals value of basic type.
+func decodeValue(path string, data []byte, v reflect.Value) []error {
+	err := json.Unmarshal(data, v.Addr().Interface())
+	if err == nil {
+		return nil
+	}
+
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		return []error{configErr(path, "must be %s, got %s", jsonType(typeErr.Type), typeErr.Value)}
+	}
+	return []error{configErr(path, "%v", err)}
+}
+
+// unknownKey reports key which doesn't match any of known ones.
+func unknownKey(path, key string, known []string) error {
+	msg := "unknown field"
+	for _, k := range known {
+		if strings.EqualFold(k, key) {
+			msg = fmt.Sprintf("unknown field, did you mean %q?", k)
+		}
+	}
+	return configErr(path, "%s", msg)
+}
+
+// jsonKind names kind of JSON value for error messages.
+func jsonKind(data []byte) string {
+	switch data[0] {
+	case '{':
+		return "object"
+	case '[':
+		return "array"
+	case '"':
+		return "string"
+	case 't', 'f':
+		return "bool"
+	default:
+		return "number"
+	}
+}
+
+// jsonType names JSON value expected for Go type t.
+func jsonType(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "an integer"
+	case reflect.Float32, reflect.Float64:
+		return "a number"
+	case reflect.String:
+		return "a string"
+	case reflect.Bool:
+		return "a bool"
+	case reflect.Slice, reflect.Array:
+		return "a list"
+	default:
+		return "an object"
+	}
+}
+
+// fieldIndex returns index of the field of struct t with the given json key.
+func fieldIndex(t reflect.Type, key string) int {
+	for i := range t.NumField() {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		if name == key && name != "-" {
+			return i
+		}
+	}
+	return -1
+}
+
+// withoutPaths drops problems of fields which couldn't be decoded,
+// as their zero values are reported by Validate as well.
+func withoutPaths(err error, decodeErrs []error) []error {
+	if err == nil {
+		return nil
+	}
+	all := []error{err}
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		all = joined.Unwrap()
+	}
+
+	var errs []error
+	for _, e := range all {
+		var confErr *ConfigError
+		if errors.As(e, &confErr) && slices.ContainsFunc(decodeErrs, func(d error) bool {
+			var decodeErr *ConfigError
+			return errors.As(d, &decodeErr) && (confErr.Path == decodeErr.Path ||
+				strings.HasPrefix(confErr.Path, decodeErr.Path+".") ||
+				strings.HasPrefix(confErr.Path, decodeErr.Path+"["))
+		}) {
+			continue
+		}
+		errs = append(errs, e)
+	}
+	return errs
+}
+
+func jsonKeys(t reflect.Type) []string {
+	keys := make([]string, 0, t.NumField())
+	for i := range t.NumField() {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		if name != "" && name != "-" {
+			keys = append(keys, name)
+		}
+	}
+	return keys
+}
@@ -0,0 +1,284 @@
+package biathlon
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestParseConfigErrors(t *testing.T) {
+	path := writeConfig(t, `{
+		"laps": "2",
+		"lapLen": 3651,
+		"penaltyLen": -50,
+		"firinglines": 1,
+		"startDelta": "00:00:30"
+	}`)
+
+	_, err := ParseConfig(path)
+	if !errors.Is(err, ErrInvalidConfig) {
+		t.Fatalf("got %v, want ErrInvalidConfig", err)
+	}
+
+	lines := strings.Split(err.Error(), "\n")
+	for _, want := range []string{
+		`$.firinglines: unknown field, did you mean "firingLines"?`,
+		`$.laps: must be an integer, got string`,
+		`$.penaltyLen: must be positive, got -50`,
+		`$.start: is required`,
+	} {
+		if !slices.Contains(lines, want) {
+			t.Errorf("missing %q in:\n%v", want, err)
+		}
+	}
+	for _, line := range lines {
+		if strings.HasPrefix(line, "$.laps: must be at least") {
+			t.Errorf("laps of wrong type are reported twice:\n%v", err)
+		}
+	}
+}
+
+func TestParseConfigFiringLines(t *testing.T) {
+	path := writeConfig(t, `{
+		"laps": 2,
+		"lapLen": 3651,
+		"penaltyLen": 50,
+		"firingLines": 3,
+		"start": "09:30:00",
+		"startDelta": "00:00:30"
+	}`)
+
+	_, err := ParseConfig(path)
+	want := "$.firingLines: must not exceed laps, got 3 firing lines for 2 laps"
+	if err == nil || err.Error() != want {
+		t.Errorf("got %v, want %q", err, want)
+	}
+}
+
+func TestParseConfigLapLengths(t *testing.T) {
+	path := writeConfig(t, `{
+		"laps": 3,
+		"lapLen": [2500, 3300, 3300],
+		"penaltyLen": 50,
+		"firingLines": 2,
+		"start": "09:30:00",
+		"startDelta": "00:00:30"
+	}`)
+
+	conf, err := ParseConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for lap, want := range []float64{2500, 3300, 3300} {
+		if got := conf.LapLength(lap + 1); got != want {
+			t.Errorf("LapLength(%d) = %g, want %g", lap+1, got, want)
+		}
+	}
+
+	path = writeConfig(t, `{
+		"laps": 3,
+		"lapLen": [2500, -3300],
+		"penaltyLen": 50,
+		"firingLines": 2,
+		"start": "09:30:00",
+		"startDelta": "00:00:30"
+	}`)
+
+	_, err = ParseConfig(path)
+	if err == nil {
+		t.Fatal("invalid lap lengths are accepted")
+	}
+	lines := strings.Split(err.Error(), "\n")
+	for _, want := range []string{
+		`$.lapLen: must have length of every lap, got 2 lengths for 3 laps`,
+		`$.lapLen[1]: must be positive, got -3300`,
+	} {
+		if !slices.Contains(lines, want) {
+			t.Errorf("missing %q in:\n%v", want, err)
+		}
+	}
+}
+
+func TestConfigShotsAt(t *testing.T) {
+	conf := Config{FiringLines: 2}
+	if got := conf.ShotsAt(2); got != DefaultShotsPerRange {
+		t.Errorf("ShotsAt() without shotsPerRange = %d, want %d", got, DefaultShotsPerRange)
+	}
+
+	conf.ShotsPerRange = oneOrMany[int]{5, 3}
+	if got := conf.ShotsAt(2); got != 3 {
+		t.Errorf("ShotsAt(2) = %d, want 3", got)
+	}
+
+	conf.ShotsPerRange = oneOrMany[int]{5, 0, 3}
+	conf.SpareRounds = -1
+	err := conf.Validate()
+	if err == nil {
+		t.Fatal("invalid shots per range are accepted")
+	}
+	lines := strings.Split(err.Error(), "\n")
+	for _, want := range []string{
+		`$.shotsPerRange: must have shots of every range, got 3 numbers for 2 firing lines`,
+		`$.shotsPerRange[1]: must be at least 1, got 0`,
+		`$.spareRounds: must not be negative, got -1`,
+	} {
+		if !slices.Contains(lines, want) {
+			t.Errorf("missing %q in:\n%v", want, err)
+		}
+	}
+}
+
+func TestParseConfigSchedule(t *testing.T) {
+	path := writeConfig(t, `{
+		"laps": 2,
+		"lapLen": 3651,
+		"penaltyLen": 50,
+		"firingLines": 2,
+		"rangeSchedule": [
+			{"lap": 1, "range": 1, "positon": "prone"},
+			{"lap": 1, "range": 3, "position": "standing"}
+		],
+		"start": "09:30:00",
+		"startDelta": "00:00:30"
+	}`)
+
+	_, err := ParseConfig(path)
+	if err == nil {
+		t.Fatal("invalid schedule is accepted")
+	}
+	lines := strings.Split(err.Error(), "\n")
+	for _, want := range []string{
+		`$.rangeSchedule[0].positon: unknown field`,
+		`$.rangeSchedule[0].position: must be "prone" or "standing", got ""`,
+		`$.rangeSchedule[1].lap: lap 1 is already scheduled`,
+		`$.rangeSchedule[1].range: must be within 1..2, got 3`,
+	} {
+		if !slices.Contains(lines, want) {
+			t.Errorf("missing %q in:\n%v", want, err)
+		}
+	}
+}
+
+func TestConfigFormat(t *testing.T) {
+	conf := Config{Laps: 1, LapLen: oneOrMany[float64]{3000}, StartDelta: duration(time.Minute)}
+	conf.Start = justTime(time.Date(0, 1, 1, 9, 30, 0, 0, time.UTC))
+
+	if conf.RaceFormat() != Sprint || conf.MissPenalty() != 0 {
+		t.Errorf("default format is %q with miss penalty %v", conf.RaceFormat(), conf.MissPenalty())
+	}
+	if err := conf.Validate(); err == nil || err.Error() != "$.penaltyLen: must be positive, got 0" {
+		t.Errorf("Validate() of sprint without penaltyLen = %v", err)
+	}
+
+	// Penalty laps aren't skied in Individual format.
+	conf.Format = Individual
+	if err := conf.Validate(); err != nil {
+		t.Errorf("Validate() of individual = %v", err)
+	}
+	if got := conf.MissPenalty(); got != DefaultPenaltyTime {
+		t.Errorf("MissPenalty() = %v, want %v", got, DefaultPenaltyTime)
+	}
+	conf.PenaltyTime = duration(45 * time.Second)
+	if got := conf.MissPenalty(); got != 45*time.Second {
+		t.Errorf("MissPenalty() = %v, want 45s", got)
+	}
+
+	conf.Format = "marathon"
+	if err := conf.Validate(); err == nil || !strings.HasPrefix(err.Error(), "$.format: must be") {
+		t.Errorf("Validate() of unknown format = %v", err)
+	}
+}
+
+func TestParseConfigTeams(t *testing.T) {
+	path := writeConfig(t, `{
+		"format": "relay",
+		"laps": 1,
+		"lapLen": 3000,
+		"penaltyLen": 150,
+		"firingLines": 1,
+		"start": "09:30:00",
+		"teams": [
+			{"name": "Norway", "members": [1, 2]},
+			{"name": "Norway", "members": [3]},
+			{"name": "", "members": [2, 4]}
+		]
+	}`)
+
+	_, err := ParseConfig(path)
+	if err == nil {
+		t.Fatal("invalid teams are accepted")
+	}
+	lines := strings.Split(err.Error(), "\n")
+	for _, want := range []string{
+		`$.teams[1].name: team "Norway" already exists`,
+		`$.teams[1].members: must have 2 legs like the first team, got 1`,
+		`$.teams[2].name: is required`,
+		`$.teams[2].members[0]: competitor 2 is already in team "Norway"`,
+	} {
+		if !slices.Contains(lines, want) {
+			t.Errorf("missing %q in:\n%v", want, err)
+		}
+	}
+
+	conf := Config{Teams: []Team{{Name: "France", Members: []int{4, 5, 6}}}}
+	if team, leg, ok := conf.TeamLeg(5); !ok || team.Name != "France" || leg != 2 {
+		t.Errorf("TeamLeg(5) = %q, %d, %t", team.Name, leg, ok)
+	}
+	if _, _, ok := conf.TeamLeg(1); ok {
+		t.Error("TeamLeg(1) found a team")
+	}
+}
+
+func TestConfigPenaltyLoops(t *testing.T) {
+	conf := Config{PenaltyLen: 150}
+	// 7 m/s make only one loop of 150 m in 40 seconds.
+	if got := conf.LoopsSkied(40 * time.Second); got != 1 {
+		t.Errorf("LoopsSkied(40s) = %d, want 1", got)
+	}
+	conf.MaxPenaltySpeed = 10
+	if got := conf.LoopsSkied(40 * time.Second); got != 2 {
+		t.Errorf("LoopsSkied(40s) at 10 m/s = %d, want 2", got)
+	}
+
+	if got := conf.SkipPenalty(); got != DefaultSkippedLoopPenalty {
+		t.Errorf("SkipPenalty() = %v, want %v", got, DefaultSkippedLoopPenalty)
+	}
+	conf.SkippedLoopPenalty = duration(time.Minute)
+	if got := conf.SkipPenalty(); got != time.Minute {
+		t.Errorf("SkipPenalty() = %v, want 1m", got)
+	}
+	conf.PenaltySanction = DisqualifySanction
+	if got := conf.SkipPenalty(); got != 0 {
+		t.Errorf("SkipPenalty() with disqualification = %v, want 0", got)
+	}
+
+	conf.MaxPenaltySpeed = -1
+	conf.PenaltySanction = "warning"
+	err := conf.Validate()
+	if err == nil {
+		t.Fatal("invalid penalty loops config is accepted")
+	}
+	lines := strings.Split(err.Error(), "\n")
+	for _, want := range []string{
+		`$.maxPenaltySpeed: must not be negative, got -1`,
+		`$.penaltySanction: must be "time" or "disqualify", got "warning"`,
+	} {
+		if !slices.Contains(lines, want) {
+			t.Errorf("missing %q in:\n%v", want, err)
+		}
+	}
+}
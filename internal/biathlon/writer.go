@@ -0,0 +1,58 @@
+package biathlon
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FormatEvent converts Event back into the line ParseEvent accepts,
+// so that processed events could be written down and replayed later.
+func FormatEvent(e Event) string {
+	var sb strings.Builder
+	if e.Seq != 0 {
+		sb.WriteString(fmt.Sprintf("@%d ", e.Seq))
+	}
+	sb.WriteString(fmt.Sprintf("[%s] %d %d", formatEventTime(e.TimeStamp), e.Type, e.CompetitorID))
+
+	param, ok := eventParams[e.Type]
+	if !ok || len(e.ExtraParams) == 0 {
+		return sb.String()
+	}
+
+	sb.WriteString(" ")
+	switch param.kind {
+	case timeParam:
+		sb.WriteString(formatEventTime(e.ExtraParams[0].(time.Time)))
+	case intParam:
+		sb.WriteString(strconv.Itoa(e.ExtraParams[0].(int)))
+	case textParam:
+		sb.WriteString(e.ExtraParams[0].(string))
+	case reasonParam:
+		sb.WriteString(e.ExtraParams[0].(Reason).String())
+	}
+
+	return sb.String()
+}
+
+// CanonicalLogger writes processed and generated events in the input format,
+// so that the log could be fed to the processor again. Errors are skipped.
+type CanonicalLogger struct {
+	out io.Writer
+}
+
+func NewCanonicalLogger(out io.Writer) *CanonicalLogger {
+	return &CanonicalLogger{
+		out: out,
+	}
+}
+
+func (l *CanonicalLogger) Error(time.Time, error) {}
+
+func (l *CanonicalLogger) Event(e Event) {
+	if _, err := fmt.Fprintln(l.out, FormatEvent(e)); err != nil {
+		fmt.Printf("Logger error: %s\n", err.Error())
+	}
+}
@@ -0,0 +1,157 @@
+package biathlon
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFormatEventRoundTrip(t *testing.T) {
+	tests := []struct {
+		line  string
+		event Event
+	}{
+		{
+			"[09:05:59.867] 1 1",
+			Event{TimeStamp: at(9, 5, 59, 867), Type: Register, CompetitorID: 1},
+		},
+		{
+			"[09:15:00.841] 2 1 09:30:00.000",
+			Event{
+				TimeStamp:    at(9, 15, 0, 841),
+				Type:         BeSheduled,
+				CompetitorID: 1,
+				ExtraParams:  []any{at(9, 30, 0, 0)},
+			},
+		},
+		{
+			"[09:49:31.659] 5 1 1",
+			Event{TimeStamp: at(9, 49, 31, 659), Type: ComeToFiringRange, CompetitorID: 1, ExtraParams: []any{1}},
+		},
+		{
+			"[09:59:03.872] 11 1 Lost in the forest",
+			Event{
+				TimeStamp:    at(9, 59, 3, 872),
+				Type:         BeUnableToContinue,
+				CompetitorID: 1,
+				ExtraParams:  []any{"Lost in the forest"},
+			},
+		},
+		{
+			"[10:00:00.000] 32 2 RangesMissed range(2)",
+			Event{
+				TimeStamp:    at(10, 0, 0, 0),
+				Type:         Disqualify,
+				CompetitorID: 2,
+				ExtraParams:  []any{Reason{Code: RangesMissed, Detail: "range(2)"}},
+			},
+		},
+		{
+			"[10:00:00.000] 32 2 LateStart",
+			Event{TimeStamp: at(10, 0, 0, 0), Type: Disqualify, CompetitorID: 2, ExtraParams: []any{Reason{Code: LateStart}}},
+		},
+		{
+			"[10:00:00.000] 34 4 1",
+			Event{TimeStamp: at(10, 0, 0, 0), Type: StartLeg, CompetitorID: 4, ExtraParams: []any{1}},
+		},
+		{
+			"[10:00:00.000] 35 1 2",
+			Event{TimeStamp: at(10, 0, 0, 0), Type: SkipPenaltyLoops, CompetitorID: 1, ExtraParams: []any{2}},
+		},
+		{
+			"@7 [2025-02-01T23:59:59.999] 4 3",
+			Event{
+				TimeStamp:    time.Date(2025, 2, 1, 23, 59, 59, 999e6, time.UTC),
+				Type:         Start,
+				CompetitorID: 3,
+				Seq:          7,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			if got := FormatEvent(tt.event); got != tt.line {
+				t.Errorf("FormatEvent() = %q, want %q", got, tt.line)
+			}
+
+			e, err := ParseEvent(tt.line)
+			if err != nil {
+				t.Fatalf("ParseEvent(): %v", err)
+			}
+			if !reflect.DeepEqual(e, tt.event) {
+				t.Errorf("ParseEvent() = %+v, want %+v", e, tt.event)
+			}
+		})
+	}
+}
+
+func TestCanonicalLogger(t *testing.T) {
+	var out strings.Builder
+	log := NewCanonicalLogger(&out)
+
+	log.Event(Event{TimeStamp: at(9, 5, 59, 867), Type: Register, CompetitorID: 1})
+	log.Error(at(9, 6, 0, 0), ErrWrongEventsSequence)
+	log.Event(Event{TimeStamp: at(10, 0, 0, 0), Type: Disqualify, CompetitorID: 1})
+
+	want := "[09:05:59.867] 1 1\n[10:00:00.000] 32 1\n"
+	if out.String() != want {
+		t.Errorf("logged %q, want %q", out.String(), want)
+	}
+}
+
+// TestProcessorSkipsReplayedEvents feeds processor with its own output,
+// generated disqualification must not be processed twice.
+func TestProcessorSkipsReplayedEvents(t *testing.T) {
+	input := strings.Join([]string{
+		"[09:05:59.867] 1 1",
+		"[09:15:00.841] 2 1 09:30:00.000",
+		"[09:31:00.000] 3 1",
+		"[09:31:00.000] 32 1",
+		"[09:31:00.000] 1 2",
+		// Same event at a later time isn't a replayed one.
+		"[09:32:00.000] 32 1",
+	}, "\n")
+	conf := Config{StartDelta: duration(time.Minute / 2)}
+
+	lines := processLines(t, conf, NewReaderSource("input", strings.NewReader(input)))
+
+	want := []string{
+		"[09:05:59.867] The competitor(1) registered",
+		"[09:15:00.841] The start time for the competitor(1) was set by a draw to 09:30:00.000",
+		"[09:31:00.000] The competitor(1) is on the start line",
+		"[09:31:00.000] The competitor(1) is disqualified",
+		"[09:31:00.000] The competitor(2) registered",
+		"error: " + ErrWrongEventsSequence.Error(),
+	}
+	expectLines(t, lines, want)
+}
+
+func TestParseEventReason(t *testing.T) {
+	e, err := ParseEvent("[10:15:00.000] 32 3 JuryDecision obstruction on the course")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Reason{Code: JuryDecision, Detail: "obstruction on the course"}
+	if e.ExtraParams[0] != want {
+		t.Errorf("got reason %+v, want %+v", e.ExtraParams[0], want)
+	}
+
+	// Disqualification without reason is a jury decision.
+	e, err = ParseEvent("[10:15:00.000] 32 3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.ExtraParams[0] != (Reason{Code: JuryDecision}) {
+		t.Errorf("got reason %+v, want jury decision", e.ExtraParams[0])
+	}
+	if got := e.ExtraParams[0].(Reason).Describe(); got != "jury decision" {
+		t.Errorf("Describe() = %q", got)
+	}
+
+	if _, err := ParseEvent("[10:15:00.000] 32 3 obstruction"); !errors.Is(err, ErrWrongEventFormat) {
+		t.Errorf("ParseEvent() with unknown reason code error = %v", err)
+	}
+}
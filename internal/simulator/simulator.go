@@ -0,0 +1,315 @@
+package simulator
+
+import (
+	"errors"
+	"math"
+	"math/rand/v2"
+	"slices"
+	"time"
+
+	"github.com/Chernovuk/biathlon-competetions/internal/biathlon"
+)
+
+// Params describe the simulated field of competitors.
+type Params struct {
+	Competitors int
+	// SpeedMean and SpeedStdDev describe normal distribution
+	// of competitors ski speed in m/s.
+	SpeedMean   float64
+	SpeedStdDev float64
+	// Accuracy is a probability to hit a target.
+	Accuracy float64
+	// DNFProb is a probability that competitor can't finish the race.
+	DNFProb float64
+	// LateStartProb is a probability that competitor misses the start interval.
+	LateStartProb float64
+	Seed          uint64
+}
+
+var (
+	ErrInvalidParams = errors.New("invalid simulation parameters")
+	ErrNoStartList   = errors.New("pursuit requires start list")
+)
+
+var dnfComments = []string{
+	"Lost in the forest",
+	"Broken ski",
+	"Broken pole",
+	"Injury",
+	"Feeling unwell",
+}
+
+const (
+	shotInterval  = 3 * time.Second
+	spareLoadTime = 5 * time.Second
+)
+
+// Simulate generates time-ordered stream of incoming events of the race.
+// The same config and params always give the same events.
+func Simulate(conf biathlon.Config, p Params) ([]biathlon.Event, error) {
+	if p.Competitors < 1 || p.SpeedMean <= 0 || p.SpeedStdDev < 0 ||
+		!isProbability(p.Accuracy) || !isProbability(p.DNFProb) || !isProbability(p.LateStartProb) {
+		return nil, ErrInvalidParams
+	}
+	if err := conf.Validate(); err != nil {
+		return nil, err
+	}
+
+	if conf.RaceFormat() == biathlon.Pursuit && len(conf.StartList) == 0 {
+		return nil, ErrNoStartList
+	}
+
+	rnd := rand.New(rand.NewPCG(p.Seed, p.Seed))
+	start := conf.StartTime()
+
+	var events []biathlon.Event
+	if conf.RaceFormat() == biathlon.Relay {
+		for _, team := range conf.Teams {
+			events = append(events, relay(team, rnd, conf, p)...)
+		}
+	}
+	for _, id := range competitorIDs(conf, p) {
+		c := competitor{
+			id:     id,
+			rnd:    rnd,
+			conf:   conf,
+			params: p,
+		}
+		c.race(start, scheduledStart(conf, id))
+		events = append(events, c.events...)
+	}
+
+	slices.SortStableFunc(events, func(a, b biathlon.Event) int {
+		return a.TimeStamp.Compare(b.TimeStamp)
+	})
+	return events, nil
+}
+
+// relay generates events of the team whose members ski legs one after another.
+// Every member who finished the leg tags over the next one.
+func relay(team biathlon.Team, rnd *rand.Rand, conf biathlon.Config, p Params) []biathlon.Event {
+	start := conf.StartTime()
+
+	members := make([]*competitor, len(team.Members))
+	var finish time.Time
+	finished := false
+	for leg, id := range team.Members {
+		c := &competitor{
+			id:     id,
+			rnd:    rnd,
+			conf:   conf,
+			params: p,
+		}
+		members[leg] = c
+
+		if leg == 0 {
+			finish, finished = c.race(start, start)
+			continue
+		}
+
+		c.register(start)
+		if finished {
+			tagOver := finish.Add(time.Second + c.jitter(5*time.Second))
+			members[leg-1].event(tagOver, biathlon.Event{Type: biathlon.TagOver})
+			finish, finished = c.ski(tagOver)
+		}
+	}
+
+	var events []biathlon.Event
+	for _, c := range members {
+		events = append(events, c.events...)
+	}
+	return events
+}
+
+// competitorIDs returns competitors of the race: all of the start list
+// in Pursuit, nobody in Relay as teams are simulated separately
+// and the given number of them otherwise.
+func competitorIDs(conf biathlon.Config, p Params) []int {
+	switch conf.RaceFormat() {
+	case biathlon.Relay:
+		return nil
+	case biathlon.Pursuit:
+		ids := make([]int, 0, len(conf.StartList))
+		for id := range conf.StartList {
+			ids = append(ids, id)
+		}
+		slices.Sort(ids)
+		return ids
+	}
+
+	ids := make([]int, p.Competitors)
+	for i := range ids {
+		ids[i] = i + 1
+	}
+	return ids
+}
+
+// scheduledStart returns start time of the competitor.
+func scheduledStart(conf biathlon.Config, id int) time.Time {
+	switch conf.RaceFormat() {
+	case biathlon.Pursuit:
+		return conf.StartTime().Add(conf.StartList[id])
+	case biathlon.MassStart:
+		return conf.StartTime()
+	default:
+		return conf.StartTime().Add(time.Duration(id-1) * time.Duration(conf.StartDelta))
+	}
+}
+
+func isProbability(p float64) bool {
+	return p >= 0 && p <= 1
+}
+
+type competitor struct {
+	id     int
+	rnd    *rand.Rand
+	conf   biathlon.Config
+	params Params
+
+	events []biathlon.Event
+}
+
+// event adds e happened at t to events of competitor.
+func (c *competitor) event(t time.Time, e biathlon.Event) {
+	e.TimeStamp = t.Truncate(time.Millisecond)
+	e.CompetitorID = c.id
+	c.events = append(c.events, e)
+}
+
+// register adds registration of competitor before the race.
+func (c *competitor) register(raceStart time.Time) {
+	c.event(raceStart.Add(-time.Hour+c.jitter(30*time.Minute)), biathlon.Event{Type: biathlon.Register})
+}
+
+// race generates events of one competitor whose start is scheduled at scheduled.
+// It returns finish time and whether competitor has finished.
+func (c *competitor) race(raceStart, scheduled time.Time) (time.Time, bool) {
+	_, latest := c.conf.StartWindow(scheduled)
+
+	c.register(raceStart)
+	// Start which isn't drawn is scheduled by processor.
+	if c.conf.RaceFormat().Drawn() {
+		c.event(raceStart.Add(-30*time.Minute+c.jitter(15*time.Minute)), biathlon.Event{
+			Type:        biathlon.BeSheduled,
+			ExtraParams: []any{scheduled},
+		})
+	}
+
+	onStartLine := scheduled.Add(-c.jitter(time.Minute))
+	c.event(onStartLine, biathlon.Event{Type: biathlon.ComeToStartLine})
+
+	started := scheduled.Add(c.jitter(latest.Sub(scheduled)))
+	if c.rnd.Float64() < c.params.LateStartProb {
+		// Late competitor is disqualified, so there is nothing more to simulate.
+		c.event(latest.Add(time.Second+c.jitter(time.Minute)), biathlon.Event{Type: biathlon.Start})
+		return time.Time{}, false
+	}
+	c.event(started, biathlon.Event{Type: biathlon.Start})
+
+	return c.ski(started)
+}
+
+// ski generates events of competitor started at the given time till the end
+// of the last lap. It returns finish time and whether competitor has finished.
+func (c *competitor) ski(started time.Time) (time.Time, bool) {
+	speed := math.Max(1, c.rnd.NormFloat64()*c.params.SpeedStdDev+c.params.SpeedMean)
+	dnfLap := 0
+	if c.rnd.Float64() < c.params.DNFProb {
+		dnfLap = 1 + c.rnd.IntN(c.conf.Laps)
+	}
+
+	now := started
+	for lap := 1; lap <= c.conf.Laps; lap++ {
+		lapTime := c.skiTime(c.conf.LapLength(lap), speed)
+
+		if lap == dnfLap {
+			comment := dnfComments[c.rnd.IntN(len(dnfComments))]
+			c.event(now.Add(c.jitter(lapTime)), biathlon.Event{
+				Type:        biathlon.BeUnableToContinue,
+				ExtraParams: []any{comment},
+			})
+			return time.Time{}, false
+		}
+
+		if firingRange, ok := c.rangeOnLap(lap); ok {
+			now = now.Add(lapTime * 9 / 10)
+			now = c.shoot(now, firingRange, speed)
+			now = now.Add(lapTime / 10)
+		} else {
+			now = now.Add(lapTime)
+		}
+		c.event(now, biathlon.Event{Type: biathlon.EndMainLap})
+	}
+
+	return now, true
+}
+
+// rangeOnLap returns firing range competitor visits on the lap. Without
+// range schedule it's range lap number on the first FiringLines laps.
+func (c *competitor) rangeOnLap(lap int) (int, bool) {
+	if len(c.conf.RangeSchedule) > 0 {
+		slot, ok := c.conf.ScheduledRange(lap)
+		return slot.Range, ok
+	}
+	return lap, lap <= c.conf.FiringLines
+}
+
+// shoot simulates firing range visit and penalty laps after it.
+// It returns time when competitor is back on the main lap.
+func (c *competitor) shoot(now time.Time, firingRange int, speed float64) time.Time {
+	c.event(now, biathlon.Event{Type: biathlon.ComeToFiringRange, ExtraParams: []any{firingRange}})
+
+	var standing []int
+	for target := 1; target <= c.conf.ShotsAt(firingRange); target++ {
+		now = now.Add(shotInterval + c.jitter(time.Second))
+		if c.rnd.Float64() < c.params.Accuracy {
+			c.event(now, biathlon.Event{Type: biathlon.HitTarget, ExtraParams: []any{target}})
+		} else {
+			standing = append(standing, target)
+		}
+	}
+
+	// Spare rounds are loaded by hand one by one while targets are standing.
+	for spare := 0; spare < c.conf.SpareRounds && len(standing) > 0; spare++ {
+		now = now.Add(spareLoadTime + c.jitter(2*time.Second))
+		c.event(now, biathlon.Event{Type: biathlon.LoadSpare})
+		now = now.Add(shotInterval + c.jitter(time.Second))
+		if c.rnd.Float64() < c.params.Accuracy {
+			c.event(now, biathlon.Event{Type: biathlon.HitTarget, ExtraParams: []any{standing[0]}})
+			standing = standing[1:]
+		}
+	}
+	misses := len(standing)
+
+	now = now.Add(5*time.Second + c.jitter(5*time.Second))
+	c.event(now, biathlon.Event{Type: biathlon.LeaveFiringRange})
+	// Misses of Individual format are punished by time, not by penalty laps.
+	if misses == 0 || c.conf.RaceFormat() == biathlon.Individual {
+		return now
+	}
+
+	now = now.Add(10*time.Second + c.jitter(10*time.Second))
+	c.event(now, biathlon.Event{Type: biathlon.EnterPenaltyLap})
+	// Penalty loops are skied well below the speed limit, so that processor
+	// doesn't take fast skiers for skipping them.
+	loopSpeed := math.Min(speed, 0.85*c.conf.PenaltySpeedLimit())
+	now = now.Add(c.skiTime(float64(misses)*c.conf.PenaltyLen, loopSpeed))
+	c.event(now, biathlon.Event{Type: biathlon.LeavePenaltyLap})
+
+	return now
+}
+
+// skiTime returns time to ski distance with speed varying a little.
+func (c *competitor) skiTime(distance, speed float64) time.Duration {
+	actual := math.Max(1, speed*(1+c.rnd.NormFloat64()*0.03))
+	return time.Duration(distance / actual * float64(time.Second))
+}
+
+// jitter returns random duration in [0, max).
+func (c *competitor) jitter(max time.Duration) time.Duration {
+	if max <= 0 {
+		return 0
+	}
+	return time.Duration(c.rnd.Int64N(int64(max)))
+}
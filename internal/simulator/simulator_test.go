@@ -0,0 +1,120 @@
+package simulator
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Chernovuk/biathlon-competetions/internal/biathlon"
+)
+
+func testConfig(t *testing.T) biathlon.Config {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "config.json")
+	content := `{
+		"laps": 2,
+		"lapLen": 3651,
+		"penaltyLen": 50,
+		"firingLines": 1,
+		"start": "09:30:00",
+		"startDelta": "00:00:30"
+	}`
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	conf, err := biathlon.ParseConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return conf
+}
+
+func testParams(seed uint64) Params {
+	return Params{
+		Competitors:   30,
+		SpeedMean:     6.5,
+		SpeedStdDev:   0.8,
+		Accuracy:      0.85,
+		DNFProb:       0.05,
+		LateStartProb: 0.03,
+		Seed:          seed,
+	}
+}
+
+func TestSimulateIsDeterministic(t *testing.T) {
+	conf := testConfig(t)
+
+	first, err := Simulate(conf, testParams(7))
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := Simulate(conf, testParams(7))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(first, second) {
+		t.Error("the same seed gave different races")
+	}
+
+	other, err := Simulate(conf, testParams(8))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reflect.DeepEqual(first, other) {
+		t.Error("different seeds gave the same race")
+	}
+}
+
+// errorsLogger counts errors and skipped penalty loops reported by processor.
+type errorsLogger struct {
+	errs    []error
+	skipped int
+}
+
+func (l *errorsLogger) Event(e biathlon.Event) {
+	if e.Type == biathlon.SkipPenaltyLoops {
+		l.skipped++
+	}
+}
+
+func (l *errorsLogger) Error(_ time.Time, err error) {
+	l.errs = append(l.errs, err)
+}
+
+func TestSimulatedRaceIsProcessed(t *testing.T) {
+	conf := testConfig(t)
+
+	for seed := uint64(1); seed <= 10; seed++ {
+		events, err := Simulate(conf, testParams(seed))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		log := &errorsLogger{}
+		p := biathlon.NewProcessor(conf, biathlon.NewSliceSource("simulated", events))
+		p.SetLogger(log)
+		p.Start()
+
+		if len(log.errs) != 0 {
+			t.Errorf("seed %d: processing errors %v", seed, log.errs)
+		}
+		// Simulated competitors ski all their penalty loops, even the fastest ones.
+		if log.skipped != 0 {
+			t.Errorf("seed %d: %d competitors are penalized for skipped loops", seed, log.skipped)
+		}
+	}
+}
+
+func TestSimulateInvalidParams(t *testing.T) {
+	params := testParams(1)
+	params.Accuracy = 1.5
+
+	if _, err := Simulate(testConfig(t), params); !errors.Is(err, ErrInvalidParams) {
+		t.Errorf("Simulate() error = %v, want %v", err, ErrInvalidParams)
+	}
+}
@@ -0,0 +1,104 @@
+package statistics
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrWrongResultFormat = errors.New("wrong result format: [HH:MM:SS.sss] CompetitorID ... required")
+	ErrNoFinishers       = errors.New("no finished competitors")
+)
+
+// ReadStartList makes pursuit start list from the resulting table
+// of the previous race written to the file.
+func ReadStartList(filePath string) (map[int]time.Duration, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open previous race results: %w", err)
+	}
+	defer f.Close()
+
+	results, err := ParseResults(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read previous race results %v: %w", filePath, err)
+	}
+	if len(results) == 0 {
+		return nil, fmt.Errorf("%w in previous race results %v", ErrNoFinishers, filePath)
+	}
+	return StartList(results), nil
+}
+
+// ParseResults reads total times of finished competitors from the resulting
+// table printed by a previous race. Rows of competitors who haven't finished
+// and lines which are not rows of the table are skipped.
+func ParseResults(r io.Reader) (map[int]time.Duration, error) {
+	results := make(map[int]time.Duration)
+
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if !strings.HasPrefix(line, "[") {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			return nil, fmt.Errorf("line %d: %w", n, ErrWrongResultFormat)
+		}
+
+		total, err := parseDuration(strings.Trim(fields[0], "[]"))
+		if err != nil {
+			// NotStarted, NotFinished and Disqualified competitors have no time.
+			continue
+		}
+		id, err := strconv.Atoi(fields[1])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w: %w", n, ErrWrongResultFormat, err)
+		}
+		results[id] = total
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// StartList converts total times of the previous race into time
+// behind the winner, which is a start gap of every competitor in pursuit.
+func StartList(results map[int]time.Duration) map[int]time.Duration {
+	var best time.Duration
+	first := true
+	for _, total := range results {
+		if first || total < best {
+			best = total
+			first = false
+		}
+	}
+
+	gaps := make(map[int]time.Duration, len(results))
+	for id, total := range results {
+		gaps[id] = total - best
+	}
+	return gaps
+}
+
+// parseDuration is the inverse of formatDuration.
+func parseDuration(s string) (time.Duration, error) {
+	var h, m, sec, ms int
+	if _, err := fmt.Sscanf(s, "%d:%d:%d.%d", &h, &m, &sec, &ms); err != nil {
+		return 0, err
+	}
+	d := time.Duration(h)*time.Hour +
+		time.Duration(m)*time.Minute +
+		time.Duration(sec)*time.Second +
+		time.Duration(ms)*time.Millisecond
+	return d, nil
+}
@@ -0,0 +1,43 @@
+package statistics
+
+import (
+	"maps"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseResults(t *testing.T) {
+	table := strings.Join([]string{
+		"Race date: 2025-02-01",
+		"[00:29:03.872] 1 [{00:29:03.872, 2.093}] [{00:01:44.296, 0.481}] 4/5 3651m",
+		"[00:30:00.500] 3 [{00:30:00.500, 2.028}] 5/5 3651m",
+		"[NotFinished] 2 [{,}] 0/0 0m",
+		"Hit rate prone: 90.0% (9/10)",
+	}, "\n")
+
+	results, err := ParseResults(strings.NewReader(table))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[int]time.Duration{
+		1: 29*time.Minute + 3872*time.Millisecond,
+		3: 30*time.Minute + 500*time.Millisecond,
+	}
+	if !maps.Equal(results, want) {
+		t.Errorf("ParseResults() = %v, want %v", results, want)
+	}
+
+	gaps := StartList(results)
+	wantGaps := map[int]time.Duration{1: 0, 3: 56628 * time.Millisecond}
+	if !maps.Equal(gaps, wantGaps) {
+		t.Errorf("StartList() = %v, want %v", gaps, wantGaps)
+	}
+}
+
+func TestParseResultsErrors(t *testing.T) {
+	_, err := ParseResults(strings.NewReader("[00:29:03.872] one [{,}] 0/0 0m"))
+	if err == nil || !strings.HasPrefix(err.Error(), "line 1: ") {
+		t.Errorf("ParseResults() error = %v, want line 1 error", err)
+	}
+}
@@ -4,8 +4,15 @@ import (
 	"fmt"
 	"strings"
 	"time"
+
+	"github.com/Chernovuk/biathlon-competetions/internal/biathlon"
 )
 
+type PositionShooting struct {
+	Position biathlon.Position
+	Shooting
+}
+
 type Result struct {
 	Result       string
 	CompetitorID int
@@ -19,12 +26,28 @@ type Result struct {
 	}
 	TotalHits  int
 	TotalShots int
+	// ByPosition is shooting in prone and standing positions
+	// if ranges are scheduled.
+	ByPosition []PositionShooting
+	// Ranges are visits of firing ranges if spare rounds are allowed.
+	Ranges []RangeShooting
+	// Penalty is time added to the result of finished competitor
+	// for misses in Individual format and for skipped penalty loops.
+	Penalty time.Duration
+	// PhotoFinish is set if another competitor has finished at the same time
+	// in race ranked by finish order.
+	PhotoFinish bool
+	// Reason tells why competitor is disqualified.
+	Reason   biathlon.ReasonCode
+	finished bool
+	// Distance is a total length of completed main laps in meters.
+	Distance float64
 }
 
 func (r Result) String() string {
 	var sb strings.Builder
 
-	sb.WriteString(fmt.Sprintf("[%s] %d ", r.Result, r.CompetitorID))
+	sb.WriteString(fmt.Sprintf("[%s] %d ", withReason(r.Result, r.Reason), r.CompetitorID))
 
 	sb.WriteString("[")
 	for i, lap := range r.LapsInfo {
@@ -61,10 +84,91 @@ func (r Result) String() string {
 	}
 
 	sb.WriteString(fmt.Sprintf(" %d/%d", r.TotalHits, r.TotalShots))
+	if len(r.ByPosition) > 0 {
+		sb.WriteString(" (")
+		for i, ps := range r.ByPosition {
+			if i > 0 {
+				sb.WriteString(", ")
+			}
+			sb.WriteString(fmt.Sprintf("%s %d/%d", ps.Position, ps.Hits, ps.Shots))
+		}
+		sb.WriteString(")")
+	}
+	if len(r.Ranges) > 0 {
+		sb.WriteString(" [")
+		for i, rs := range r.Ranges {
+			if i > 0 {
+				sb.WriteString(", ")
+			}
+			sb.WriteString(fmt.Sprintf(
+				"{range(%d): %d shots, %d spares, %d loops}",
+				rs.Range,
+				rs.Shots(),
+				rs.Spares,
+				rs.PenaltyLoops(),
+			))
+		}
+		sb.WriteString("]")
+	}
+	if r.Penalty > 0 {
+		sb.WriteString(fmt.Sprintf(" +%gmin", r.Penalty.Minutes()))
+	}
+	sb.WriteString(fmt.Sprintf(" %.0fm", r.Distance))
+	if r.PhotoFinish {
+		sb.WriteString(" photo-finish")
+	}
 
 	return sb.String()
 }
 
+// LegSplit is a result of one leg of relay.
+type LegSplit struct {
+	CompetitorID int
+	// Result is time of the leg or status of competitor who hasn't finished it.
+	Result     string
+	Reason     biathlon.ReasonCode
+	TotalHits  int
+	TotalShots int
+	// Penalty is time added to the leg for skipped penalty loops.
+	Penalty time.Duration
+}
+
+type TeamResult struct {
+	Team   string
+	Result string
+	Legs   []LegSplit
+}
+
+func (r TeamResult) String() string {
+	var sb strings.Builder
+
+	sb.WriteString(fmt.Sprintf("[%s] %s", r.Result, r.Team))
+	for i, leg := range r.Legs {
+		sb.WriteString(fmt.Sprintf(
+			" {%d: %d %s %d/%d",
+			i+1,
+			leg.CompetitorID,
+			withReason(leg.Result, leg.Reason),
+			leg.TotalHits,
+			leg.TotalShots,
+		))
+		if leg.Penalty > 0 {
+			sb.WriteString(fmt.Sprintf(" +%gmin", leg.Penalty.Minutes()))
+		}
+		sb.WriteString("}")
+	}
+
+	return sb.String()
+}
+
+// withReason appends reason code to the status of disqualified competitor.
+func withReason(result string, reason biathlon.ReasonCode) string {
+	if reason == "" {
+		return result
+	}
+	return fmt.Sprintf("%s: %s", result, reason)
+}
+
 // formatDuration prints a time.Duration as HH:MM:SS.sss.
 func formatDuration(d time.Duration) string {
 	h := int(d / time.Hour)
@@ -2,6 +2,7 @@ package statistics
 
 import (
 	"slices"
+	"strings"
 	"time"
 
 	"github.com/Chernovuk/biathlon-competetions/internal/biathlon"
@@ -21,30 +22,92 @@ type PenaltyLapInfo struct {
 	AvgSpeed  float64
 }
 
+// Shooting counts hits and shots.
+type Shooting struct {
+	Hits  int
+	Shots int
+}
+
+// RangeShooting describes one visit of the firing range.
+type RangeShooting struct {
+	Range   int
+	Targets int
+	Hits    int
+	// Spares is number of hand-loaded rounds used after the main ones.
+	Spares int
+}
+
+// Shots returns number of rounds fired on the range.
+func (r RangeShooting) Shots() int {
+	return r.Targets + r.Spares
+}
+
+// PenaltyLoops returns number of penalty loops owed for targets still standing.
+func (r RangeShooting) PenaltyLoops() int {
+	return r.Targets - r.Hits
+}
+
 type Competitor struct {
-	ID                 int
-	Status             string
+	ID     int
+	Status string
+	// Reason is set if competitor is disqualified.
+	Reason             biathlon.ReasonCode
 	ScheduledStartTime time.Time
 	FinishTime         time.Time
 	TotalHits          int
 	TotalShots         int
-	LapsInfo           []LapInfo
-	PenaltiesInfo      []PenaltyLapInfo
+	// ByPosition breaks down shooting by position if ranges are scheduled.
+	ByPosition    map[biathlon.Position]Shooting
+	Ranges        []RangeShooting
+	SkippedLoops  int
+	CurrentRange  int
+	LapsInfo      []LapInfo
+	PenaltiesInfo []PenaltyLapInfo
 }
 
 type Statistics struct {
-	laps            int
-	lapLen          float64
-	penaltyLen      float64
+	laps        int
+	lapLens     []float64
+	shots       []int
+	positions   []biathlon.Position
+	penaltyLen  float64
+	missPenalty time.Duration
+	skipPenalty time.Duration
+	spares      bool
+	// Results are counted from raceStart instead of the actual start of
+	// every competitor if fromRaceStart is set, so that finish order is the ranking.
+	fromRaceStart   bool
+	raceStart       time.Time
+	teams           []biathlon.Team
 	competitorsInfo map[int]Competitor
 }
 
 func New(c biathlon.Config) *Statistics {
+	lapLens := make([]float64, c.Laps)
+	for i := range lapLens {
+		lapLens[i] = c.LapLength(i + 1)
+	}
+
+	shots := make([]int, c.FiringLines)
+	positions := make([]biathlon.Position, c.FiringLines)
+	for i := range shots {
+		shots[i] = c.ShotsAt(i + 1)
+		positions[i] = c.RangePosition(i + 1)
+	}
+
 	return &Statistics{
 		competitorsInfo: make(map[int]Competitor),
 		laps:            c.Laps,
-		lapLen:          c.LapLen,
+		lapLens:         lapLens,
+		shots:           shots,
+		positions:       positions,
 		penaltyLen:      c.PenaltyLen,
+		missPenalty:     c.MissPenalty(),
+		skipPenalty:     c.SkipPenalty(),
+		spares:          c.SpareRounds > 0,
+		fromRaceStart:   c.RaceFormat().RankedByFinish(),
+		raceStart:       c.StartTime(),
+		teams:           c.Teams,
 	}
 }
 
@@ -56,7 +119,18 @@ func (s *Statistics) GetResults() []Result {
 			TotalHits:    competitor.TotalHits,
 			TotalShots:   competitor.TotalShots,
 		}
-		for _, lap := range competitor.LapsInfo {
+		if s.spares {
+			res.Ranges = competitor.Ranges
+		}
+		for _, pos := range []biathlon.Position{biathlon.Prone, biathlon.Standing} {
+			if shooting, ok := competitor.ByPosition[pos]; ok {
+				res.ByPosition = append(res.ByPosition, PositionShooting{pos, shooting})
+			}
+		}
+		for i, lap := range competitor.LapsInfo {
+			if lap.Duration > 0 {
+				res.Distance += s.lapLens[i]
+			}
 			v := struct {
 				duration time.Duration
 				avgSpeed float64
@@ -76,23 +150,123 @@ func (s *Statistics) GetResults() []Result {
 			}
 			res.PenaltyLapsInfo = append(res.PenaltyLapsInfo, v)
 		}
-		if competitor.FinishTime.IsZero() {
+		// Competitor may be disqualified after the finish, e.g. for missed ranges.
+		if competitor.FinishTime.IsZero() || competitor.Status != "" {
 			res.Result = competitor.Status
+			res.Reason = competitor.Reason
 		} else {
-			res.Result = formatDuration(competitor.FinishTime.Sub(competitor.LapsInfo[0].StartTime))
+			res.Penalty = s.penalty(competitor)
+			start := competitor.LapsInfo[0].StartTime
+			if s.fromRaceStart {
+				start = s.raceStart
+			}
+			total := competitor.FinishTime.Sub(start) + res.Penalty
+			res.Result = formatDuration(total)
+			res.finished = true
 		}
 		resultingTable = append(resultingTable, res)
 	}
 	slices.SortFunc(resultingTable, cmp)
+	if s.fromRaceStart {
+		markTies(resultingTable)
+	}
 
 	return resultingTable
 }
 
+// markTies marks competitors finished at the same time, so that their
+// places are resolved by photo finish. Table must be sorted.
+func markTies(table []Result) {
+	for i := 1; i < len(table); i++ {
+		prev, curr := &table[i-1], &table[i]
+		if prev.finished && curr.finished && prev.Result == curr.Result {
+			prev.PhotoFinish = true
+			curr.PhotoFinish = true
+		}
+	}
+}
+
+// penalty returns time added to the result of finished competitor.
+func (s *Statistics) penalty(c Competitor) time.Duration {
+	return time.Duration(c.misses())*s.missPenalty +
+		time.Duration(c.SkippedLoops)*s.skipPenalty
+}
+
+// misses returns number of targets left standing on all ranges.
+func (c Competitor) misses() int {
+	misses := 0
+	for _, r := range c.Ranges {
+		misses += r.PenaltyLoops()
+	}
+	return misses
+}
+
+// TeamResults returns relay teams sorted by their result. Team result
+// is counted from the start of the race till the finish of the last leg
+// with penalties of all legs added.
+func (s *Statistics) TeamResults() []TeamResult {
+	table := make([]TeamResult, 0, len(s.teams))
+	for _, team := range s.teams {
+		res := TeamResult{Team: team.Name}
+		var penalty time.Duration
+		for _, id := range team.Members {
+			competitor := s.competitorsInfo[id]
+			split := LegSplit{
+				CompetitorID: id,
+				Result:       competitor.Status,
+				Reason:       competitor.Reason,
+				TotalHits:    competitor.TotalHits,
+				TotalShots:   competitor.TotalShots,
+			}
+			finished := !competitor.FinishTime.IsZero() && competitor.Status == ""
+			if finished {
+				split.Penalty = s.penalty(competitor)
+				penalty += split.Penalty
+				split.Result = formatDuration(competitor.FinishTime.Sub(competitor.LapsInfo[0].StartTime) + split.Penalty)
+			} else if split.Result == "" {
+				split.Result = "NotStarted"
+			}
+			res.Legs = append(res.Legs, split)
+
+			// Team result is the status of the first leg which isn't finished.
+			if res.Result == "" && !finished {
+				res.Result = split.Result
+			}
+		}
+
+		last := s.competitorsInfo[team.Members[len(team.Members)-1]]
+		if res.Result == "" {
+			res.Result = formatDuration(last.FinishTime.Sub(s.raceStart) + penalty)
+		}
+		table = append(table, res)
+	}
+	slices.SortFunc(table, func(a, b TeamResult) int {
+		return strings.Compare(a.Result, b.Result)
+	})
+
+	return table
+}
+
+// HitRates sums shooting of all competitors by position.
+// It's empty if ranges are not scheduled.
+func (s *Statistics) HitRates() map[biathlon.Position]Shooting {
+	rates := make(map[biathlon.Position]Shooting)
+	for _, competitor := range s.competitorsInfo {
+		for pos, shooting := range competitor.ByPosition {
+			total := rates[pos]
+			total.Hits += shooting.Hits
+			total.Shots += shooting.Shots
+			rates[pos] = total
+		}
+	}
+	return rates
+}
+
 func cmp(a, b Result) int {
 	if a.Result < b.Result {
 		return -1
 	} else if a.Result == b.Result {
-		return 0
+		return a.CompetitorID - b.CompetitorID
 	} else {
 		return 1
 	}
@@ -125,7 +299,32 @@ func (s *Statistics) OnStart(e biathlon.Event) {
 
 func (s *Statistics) OnComeToFiringRange(e biathlon.Event) {
 	stat := s.competitorsInfo[e.CompetitorID]
-	stat.TotalShots += 5
+	firingRange := e.ExtraParams[0].(int)
+	stat.CurrentRange = firingRange
+	stat.Ranges = append(stat.Ranges, RangeShooting{
+		Range:   firingRange,
+		Targets: s.shots[firingRange-1],
+	})
+
+	if pos := s.positions[firingRange-1]; pos != "" && stat.ByPosition == nil {
+		stat.ByPosition = make(map[biathlon.Position]Shooting)
+	}
+
+	s.competitorsInfo[e.CompetitorID] = stat
+}
+
+// OnLeaveFiringRange counts shots of the range. They aren't counted on arrival,
+// as competitor may be disqualified or abandon the race before shooting.
+func (s *Statistics) OnLeaveFiringRange(e biathlon.Event) {
+	stat := s.competitorsInfo[e.CompetitorID]
+	shots := s.shots[stat.CurrentRange-1]
+	stat.TotalShots += shots
+
+	if pos := s.positions[stat.CurrentRange-1]; pos != "" {
+		shooting := stat.ByPosition[pos]
+		shooting.Shots += shots
+		stat.ByPosition[pos] = shooting
+	}
 
 	s.competitorsInfo[e.CompetitorID] = stat
 }
@@ -133,6 +332,34 @@ func (s *Statistics) OnComeToFiringRange(e biathlon.Event) {
 func (s *Statistics) OnHitTarget(e biathlon.Event) {
 	stat := s.competitorsInfo[e.CompetitorID]
 	stat.TotalHits++
+	stat.Ranges[len(stat.Ranges)-1].Hits++
+
+	if pos := s.positions[stat.CurrentRange-1]; pos != "" {
+		shooting := stat.ByPosition[pos]
+		shooting.Hits++
+		stat.ByPosition[pos] = shooting
+	}
+
+	s.competitorsInfo[e.CompetitorID] = stat
+}
+
+func (s *Statistics) OnLoadSpare(e biathlon.Event) {
+	stat := s.competitorsInfo[e.CompetitorID]
+	stat.TotalShots++
+	stat.Ranges[len(stat.Ranges)-1].Spares++
+
+	if pos := s.positions[stat.CurrentRange-1]; pos != "" {
+		shooting := stat.ByPosition[pos]
+		shooting.Shots++
+		stat.ByPosition[pos] = shooting
+	}
+
+	s.competitorsInfo[e.CompetitorID] = stat
+}
+
+func (s *Statistics) OnSkipPenaltyLoops(e biathlon.Event) {
+	stat := s.competitorsInfo[e.CompetitorID]
+	stat.SkippedLoops += e.ExtraParams[0].(int)
 
 	s.competitorsInfo[e.CompetitorID] = stat
 }
@@ -168,7 +395,7 @@ func (s *Statistics) OnEndMainLap(e biathlon.Event) {
 	lapInfo := stat.LapsInfo[currLap]
 	lapInfo.EndTime = e.TimeStamp
 	lapInfo.Duration = lapInfo.EndTime.Sub(lapInfo.StartTime)
-	lapInfo.AvgSpeed = s.lapLen / lapInfo.Duration.Seconds()
+	lapInfo.AvgSpeed = s.lapLens[currLap] / lapInfo.Duration.Seconds()
 
 	stat.LapsInfo[currLap] = lapInfo
 
@@ -192,10 +419,19 @@ func (s *Statistics) OnBeUnableToContinue(e biathlon.Event) {
 func (s *Statistics) OnDisqualify(e biathlon.Event) {
 	stat := s.competitorsInfo[e.CompetitorID]
 
-	if len(stat.LapsInfo) > 0 {
-		stat.Status = "Disqualified"
-	} else {
+	// Disqualify event without reason is a jury decision.
+	reason := biathlon.Reason{Code: biathlon.JuryDecision}
+	if len(e.ExtraParams) > 0 {
+		reason = e.ExtraParams[0].(biathlon.Reason)
+	}
+	stat.Reason = reason.Code
+	switch reason.Code {
+	case biathlon.LateStart, biathlon.FalseStart, biathlon.NotEntered, biathlon.DidNotStart:
+		stat.Status = "NotStarted"
+	case biathlon.DidNotFinish:
 		stat.Status = "NotFinished"
+	default:
+		stat.Status = "Disqualified"
 	}
 
 	s.competitorsInfo[e.CompetitorID] = stat
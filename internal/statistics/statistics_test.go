@@ -0,0 +1,335 @@
+package statistics
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Chernovuk/biathlon-competetions/internal/biathlon"
+)
+
+func at(h, m, s int) time.Time {
+	return time.Date(0, 1, 1, h, m, s, 0, time.UTC)
+}
+
+func TestPerLapLengths(t *testing.T) {
+	s := New(biathlon.Config{Laps: 3, LapLen: []float64{2500, 3300, 3300}})
+
+	s.OnRegister(biathlon.Event{TimeStamp: at(9, 0, 0), Type: biathlon.Register, CompetitorID: 1})
+	s.OnStart(biathlon.Event{TimeStamp: at(9, 30, 0), Type: biathlon.Start, CompetitorID: 1})
+	s.OnEndMainLap(biathlon.Event{TimeStamp: at(9, 35, 0), Type: biathlon.EndMainLap, CompetitorID: 1})
+	s.OnEndMainLap(biathlon.Event{TimeStamp: at(9, 45, 0), Type: biathlon.EndMainLap, CompetitorID: 1})
+	s.OnBeUnableToContinue(biathlon.Event{TimeStamp: at(9, 50, 0), Type: biathlon.BeUnableToContinue, CompetitorID: 1})
+
+	table := s.GetResults()
+	if len(table) != 1 {
+		t.Fatalf("got %d results, want 1", len(table))
+	}
+	// 2500m in 5 minutes and 3300m in 10 minutes, the last lap isn't completed.
+	want := "[NotFinished] 1 [{00:05:00.000, 8.333}, {00:10:00.000, 5.500}, {,}] 0/0 5800m"
+	if got := table[0].String(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestSameLengthForEveryLap(t *testing.T) {
+	s := New(biathlon.Config{Laps: 2, LapLen: []float64{3000}})
+
+	s.OnRegister(biathlon.Event{TimeStamp: at(9, 0, 0), Type: biathlon.Register, CompetitorID: 1})
+	s.OnStart(biathlon.Event{TimeStamp: at(9, 30, 0), Type: biathlon.Start, CompetitorID: 1})
+	s.OnEndMainLap(biathlon.Event{TimeStamp: at(9, 40, 0), Type: biathlon.EndMainLap, CompetitorID: 1})
+	s.OnEndMainLap(biathlon.Event{TimeStamp: at(9, 50, 0), Type: biathlon.EndMainLap, CompetitorID: 1})
+
+	got := s.GetResults()[0].String()
+	if !strings.HasSuffix(got, "[{00:10:00.000, 5.000}, {00:10:00.000, 5.000}] 0/0 6000m") {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestShotsCountedOnLeavingRange(t *testing.T) {
+	s := New(biathlon.Config{Laps: 2, LapLen: []float64{3000}, FiringLines: 2, ShotsPerRange: []int{5, 3}})
+
+	for id := 1; id <= 2; id++ {
+		s.OnRegister(biathlon.Event{TimeStamp: at(9, 0, 0), Type: biathlon.Register, CompetitorID: id})
+		s.OnStart(biathlon.Event{TimeStamp: at(9, 30, 0), Type: biathlon.Start, CompetitorID: id})
+		s.OnComeToFiringRange(biathlon.Event{
+			TimeStamp:    at(9, 35, 0),
+			Type:         biathlon.ComeToFiringRange,
+			CompetitorID: id,
+			ExtraParams:  []any{2},
+		})
+	}
+	s.OnHitTarget(biathlon.Event{TimeStamp: at(9, 35, 10), Type: biathlon.HitTarget, CompetitorID: 1, ExtraParams: []any{1}})
+	s.OnLeaveFiringRange(biathlon.Event{TimeStamp: at(9, 36, 0), Type: biathlon.LeaveFiringRange, CompetitorID: 1})
+	// The second competitor abandons the race before shooting.
+	s.OnBeUnableToContinue(biathlon.Event{TimeStamp: at(9, 36, 0), Type: biathlon.BeUnableToContinue, CompetitorID: 2})
+
+	shots := make(map[int]string)
+	for _, res := range s.GetResults() {
+		shots[res.CompetitorID] = fmt.Sprintf("%d/%d", res.TotalHits, res.TotalShots)
+	}
+	if shots[1] != "1/3" || shots[2] != "0/0" {
+		t.Errorf("got shots %v, want 1/3 and 0/0", shots)
+	}
+}
+
+func TestShootingByPosition(t *testing.T) {
+	s := New(biathlon.Config{
+		Laps:        2,
+		LapLen:      []float64{3000},
+		FiringLines: 2,
+		RangeSchedule: []biathlon.RangeSlot{
+			{Lap: 1, Range: 1, Position: biathlon.Prone},
+			{Lap: 2, Range: 2, Position: biathlon.Standing},
+		},
+	})
+
+	s.OnRegister(biathlon.Event{TimeStamp: at(9, 0, 0), Type: biathlon.Register, CompetitorID: 1})
+	s.OnStart(biathlon.Event{TimeStamp: at(9, 30, 0), Type: biathlon.Start, CompetitorID: 1})
+	for firingRange, hits := range map[int]int{1: 2, 2: 3} {
+		s.OnComeToFiringRange(biathlon.Event{
+			Type:         biathlon.ComeToFiringRange,
+			CompetitorID: 1,
+			ExtraParams:  []any{firingRange},
+		})
+		for target := 1; target <= hits; target++ {
+			s.OnHitTarget(biathlon.Event{Type: biathlon.HitTarget, CompetitorID: 1, ExtraParams: []any{target}})
+		}
+		s.OnLeaveFiringRange(biathlon.Event{Type: biathlon.LeaveFiringRange, CompetitorID: 1})
+	}
+
+	got := s.GetResults()[0].String()
+	if !strings.Contains(got, " 5/10 (prone 2/5, standing 3/5) ") {
+		t.Errorf("got %q", got)
+	}
+
+	rates := s.HitRates()
+	if rates[biathlon.Prone] != (Shooting{Hits: 2, Shots: 5}) || rates[biathlon.Standing] != (Shooting{Hits: 3, Shots: 5}) {
+		t.Errorf("HitRates() = %v", rates)
+	}
+}
+
+func TestIndividualMissPenalty(t *testing.T) {
+	s := New(biathlon.Config{Format: biathlon.Individual, Laps: 1, LapLen: []float64{3000}, FiringLines: 1})
+
+	s.OnRegister(biathlon.Event{TimeStamp: at(9, 0, 0), Type: biathlon.Register, CompetitorID: 1})
+	s.OnStart(biathlon.Event{TimeStamp: at(9, 30, 0), Type: biathlon.Start, CompetitorID: 1})
+	s.OnComeToFiringRange(biathlon.Event{Type: biathlon.ComeToFiringRange, CompetitorID: 1, ExtraParams: []any{1}})
+	for target := 1; target <= 3; target++ {
+		s.OnHitTarget(biathlon.Event{Type: biathlon.HitTarget, CompetitorID: 1, ExtraParams: []any{target}})
+	}
+	s.OnLeaveFiringRange(biathlon.Event{Type: biathlon.LeaveFiringRange, CompetitorID: 1})
+	s.OnEndMainLap(biathlon.Event{TimeStamp: at(9, 50, 0), Type: biathlon.EndMainLap, CompetitorID: 1})
+	s.OnFinish(biathlon.Event{TimeStamp: at(9, 50, 0), Type: biathlon.Finish, CompetitorID: 1})
+
+	// Two misses cost a minute each.
+	res := s.GetResults()[0]
+	if res.Result != "00:22:00.000" || res.Penalty != 2*time.Minute {
+		t.Errorf("got %q with penalty %v", res.Result, res.Penalty)
+	}
+	if !strings.Contains(res.String(), " 3/5 +2min ") {
+		t.Errorf("got %q", res.String())
+	}
+}
+
+func TestMassStartPhotoFinish(t *testing.T) {
+	s := New(biathlon.Config{
+		Format: biathlon.MassStart,
+		Laps:   1,
+		LapLen: []float64{3000},
+	})
+	finishes := map[int]time.Time{1: at(9, 40, 0), 2: at(9, 39, 0), 3: at(9, 39, 0)}
+
+	for id, finish := range finishes {
+		s.OnRegister(biathlon.Event{TimeStamp: at(9, 0, 0), Type: biathlon.Register, CompetitorID: id})
+		// Starting late doesn't matter, results are counted from the race start.
+		s.OnStart(biathlon.Event{TimeStamp: at(0, 0, id), Type: biathlon.Start, CompetitorID: id})
+		s.OnEndMainLap(biathlon.Event{TimeStamp: finish, Type: biathlon.EndMainLap, CompetitorID: id})
+		s.OnFinish(biathlon.Event{TimeStamp: finish, Type: biathlon.Finish, CompetitorID: id})
+	}
+
+	var got []string
+	for _, res := range s.GetResults() {
+		got = append(got, fmt.Sprintf("%s %d %t", res.Result, res.CompetitorID, res.PhotoFinish))
+	}
+	want := []string{
+		"09:39:00.000 2 true",
+		"09:39:00.000 3 true",
+		"09:40:00.000 1 false",
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestTeamResults(t *testing.T) {
+	var conf biathlon.Config
+	err := json.Unmarshal([]byte(`{
+		"format": "relay",
+		"laps": 1,
+		"lapLen": 3000,
+		"start": "09:30:00",
+		"teams": [
+			{"name": "Norway", "members": [1, 2]},
+			{"name": "France", "members": [3, 4]}
+		]
+	}`), &conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := New(conf)
+	legs := []struct {
+		id            int
+		start, finish time.Time
+	}{
+		{1, at(9, 30, 0), at(9, 50, 0)},
+		{2, at(9, 50, 0), at(10, 12, 0)},
+		{3, at(9, 30, 0), at(9, 49, 0)},
+		{4, at(9, 49, 0), at(10, 10, 30)},
+	}
+	for _, leg := range legs {
+		s.OnRegister(biathlon.Event{TimeStamp: at(9, 0, 0), Type: biathlon.Register, CompetitorID: leg.id})
+		// Next legs start with generated event, which is handled as a start.
+		s.OnStart(biathlon.Event{TimeStamp: leg.start, Type: biathlon.StartLeg, CompetitorID: leg.id})
+		s.OnEndMainLap(biathlon.Event{TimeStamp: leg.finish, Type: biathlon.EndMainLap, CompetitorID: leg.id})
+		s.OnFinish(biathlon.Event{TimeStamp: leg.finish, Type: biathlon.Finish, CompetitorID: leg.id})
+	}
+
+	var got []string
+	for _, team := range s.TeamResults() {
+		got = append(got, team.String())
+	}
+	want := []string{
+		"[00:40:30.000] France {1: 3 00:19:00.000 0/0} {2: 4 00:21:30.000 0/0}",
+		"[00:42:00.000] Norway {1: 1 00:20:00.000 0/0} {2: 2 00:22:00.000 0/0}",
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestSpareRounds(t *testing.T) {
+	s := New(biathlon.Config{Laps: 1, LapLen: []float64{3000}, FiringLines: 1, ShotsPerRange: []int{5}, SpareRounds: 3})
+
+	s.OnRegister(biathlon.Event{TimeStamp: at(9, 0, 0), Type: biathlon.Register, CompetitorID: 1})
+	s.OnStart(biathlon.Event{TimeStamp: at(9, 30, 0), Type: biathlon.Start, CompetitorID: 1})
+	s.OnComeToFiringRange(biathlon.Event{
+		TimeStamp:    at(9, 35, 0),
+		Type:         biathlon.ComeToFiringRange,
+		CompetitorID: 1,
+		ExtraParams:  []any{1},
+	})
+	for target := 1; target <= 3; target++ {
+		s.OnHitTarget(biathlon.Event{TimeStamp: at(9, 35, 10), Type: biathlon.HitTarget, CompetitorID: 1, ExtraParams: []any{target}})
+	}
+	// One of two spares hits a standing target, so one loop is owed.
+	s.OnLoadSpare(biathlon.Event{TimeStamp: at(9, 35, 20), Type: biathlon.LoadSpare, CompetitorID: 1})
+	s.OnHitTarget(biathlon.Event{TimeStamp: at(9, 35, 25), Type: biathlon.HitTarget, CompetitorID: 1, ExtraParams: []any{4}})
+	s.OnLoadSpare(biathlon.Event{TimeStamp: at(9, 35, 30), Type: biathlon.LoadSpare, CompetitorID: 1})
+	s.OnLeaveFiringRange(biathlon.Event{TimeStamp: at(9, 36, 0), Type: biathlon.LeaveFiringRange, CompetitorID: 1})
+
+	res := s.GetResults()[0]
+	want := []RangeShooting{{Range: 1, Targets: 5, Hits: 4, Spares: 2}}
+	if !slices.Equal(res.Ranges, want) {
+		t.Errorf("got ranges %+v, want %+v", res.Ranges, want)
+	}
+	if res.TotalHits != 4 || res.TotalShots != 7 {
+		t.Errorf("got %d/%d, want 4/7", res.TotalHits, res.TotalShots)
+	}
+	if !strings.Contains(res.String(), " 4/7 [{range(1): 7 shots, 2 spares, 1 loops}]") {
+		t.Errorf("got %q", res.String())
+	}
+}
+
+func TestSkippedLoopsPenalty(t *testing.T) {
+	var conf biathlon.Config
+	err := json.Unmarshal([]byte(`{
+		"format": "relay",
+		"laps": 1,
+		"lapLen": 3000,
+		"penaltyLen": 150,
+		"start": "09:30:00",
+		"teams": [{"name": "Norway", "members": [1, 2]}]
+	}`), &conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := New(conf)
+	for id := 1; id <= 2; id++ {
+		start := at(9, 30+20*(id-1), 0)
+		s.OnRegister(biathlon.Event{TimeStamp: at(9, 0, 0), Type: biathlon.Register, CompetitorID: id})
+		s.OnStart(biathlon.Event{TimeStamp: start, Type: biathlon.StartLeg, CompetitorID: id})
+		s.OnEndMainLap(biathlon.Event{TimeStamp: start.Add(20 * time.Minute), Type: biathlon.EndMainLap, CompetitorID: id})
+		s.OnFinish(biathlon.Event{TimeStamp: start.Add(20 * time.Minute), Type: biathlon.Finish, CompetitorID: id})
+	}
+	s.OnSkipPenaltyLoops(biathlon.Event{
+		TimeStamp:    at(10, 10, 0),
+		Type:         biathlon.SkipPenaltyLoops,
+		CompetitorID: 2,
+		ExtraParams:  []any{1},
+	})
+
+	for _, res := range s.GetResults() {
+		if res.CompetitorID == 2 && res.Penalty != biathlon.DefaultSkippedLoopPenalty {
+			t.Errorf("got penalty %v, want %v", res.Penalty, biathlon.DefaultSkippedLoopPenalty)
+		}
+	}
+	want := "[00:42:00.000] Norway {1: 1 00:20:00.000 0/0} {2: 2 00:22:00.000 0/0 +2min}"
+	if got := s.TeamResults()[0].String(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestDisqualificationReasons(t *testing.T) {
+	s := New(biathlon.Config{Laps: 1, LapLen: []float64{3000}, FiringLines: 1})
+	reasons := map[int]biathlon.ReasonCode{
+		1: biathlon.LateStart,
+		2: biathlon.DidNotFinish,
+		3: biathlon.RangesMissed,
+	}
+	for id, code := range reasons {
+		s.OnRegister(biathlon.Event{TimeStamp: at(9, 0, 0), Type: biathlon.Register, CompetitorID: id})
+		if code != biathlon.LateStart {
+			s.OnStart(biathlon.Event{TimeStamp: at(9, 30, 0), Type: biathlon.Start, CompetitorID: id})
+		}
+	}
+	// The third competitor is disqualified after the finish.
+	s.OnEndMainLap(biathlon.Event{TimeStamp: at(9, 50, 0), Type: biathlon.EndMainLap, CompetitorID: 3})
+	s.OnFinish(biathlon.Event{TimeStamp: at(9, 50, 0), Type: biathlon.Finish, CompetitorID: 3})
+	for id, code := range reasons {
+		s.OnDisqualify(biathlon.Event{
+			TimeStamp:    at(9, 50, 0),
+			Type:         biathlon.Disqualify,
+			CompetitorID: id,
+			ExtraParams:  []any{biathlon.Reason{Code: code}},
+		})
+	}
+
+	got := make(map[int]string)
+	for _, res := range s.GetResults() {
+		got[res.CompetitorID] = strings.Fields(res.String())[0]
+	}
+	want := map[int]string{1: "[NotStarted:", 2: "[NotFinished:", 3: "[Disqualified:"}
+	if !maps.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestOnDisqualifyWithoutReason(t *testing.T) {
+	s := New(biathlon.Config{Laps: 1, LapLen: []float64{3000}, FiringLines: 1})
+
+	s.OnRegister(biathlon.Event{TimeStamp: at(9, 0, 0), Type: biathlon.Register, CompetitorID: 1})
+	s.OnDisqualify(biathlon.Event{TimeStamp: at(9, 0, 0), Type: biathlon.Disqualify, CompetitorID: 1})
+
+	table := s.GetResults()
+	if len(table) != 1 {
+		t.Fatalf("got %d results, want 1", len(table))
+	}
+	if got := table[0]; got.Result != "Disqualified" || got.Reason != biathlon.JuryDecision {
+		t.Errorf("got %s: %s, want Disqualified: %s", got.Result, got.Reason, biathlon.JuryDecision)
+	}
+}